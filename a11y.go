@@ -0,0 +1,192 @@
+package hagl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is how serious an accessibility issue is
+type Severity int
+
+const (
+	// SeverityWarning is for issues that are usually, but not always, a
+	// problem, like skipped heading levels
+	SeverityWarning Severity = iota
+
+	// SeverityError is for issues that make content inaccessible, like
+	// images without alt text
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// A11yIssue is an accessibility issue found by CheckA11y
+type A11yIssue struct {
+	// Path is the location of the element with the issue, in the same format
+	// as ValidationError
+	Path string
+
+	// Rule identifies the check that found the issue, like "img-alt"
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+func (i A11yIssue) Error() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", i.Severity, i.Path, i.Message, i.Rule)
+}
+
+// CheckA11y checks the tree for common accessibility issues:
+//
+//   - img-alt: <img> without an alt attribute, which should be empty for
+//     decorative images
+//   - label: form control without a label, aria-label or aria-labelledby
+//   - link-name: <a> without text, alt text or aria-label
+//   - heading-order: heading that skips a level after the previous heading
+//   - button-type: <button> without a type, which submits forms by default
+//   - html-lang: <html> without a lang attribute
+//   - aria-role: role that isn't an ARIA role
+//
+// Issues are returned in the order of the tree. In tests, report the ones
+// with SeverityError, or all of them:
+//
+//	for _, issue := range CheckA11y(page()) {
+//		t.Error(issue)
+//	}
+func CheckA11y(node Node) []A11yIssue {
+	c := &a11yChecker{labelled: make(map[string]bool)}
+
+	eachRootElement(node, func(el *RawNode, _ []*RawNode, _ string) bool {
+		if el.tag == "label" && el.attr("for") != "" {
+			c.labelled[el.attr("for")] = true
+		}
+		return true
+	})
+
+	eachRootElement(node, c.element)
+	return c.issues
+}
+
+type a11yChecker struct {
+	issues []A11yIssue
+
+	// labelled are the ids referenced by the for attribute of a <label>
+	labelled map[string]bool
+
+	// heading is the level of the previous heading
+	heading int
+}
+
+func (c *a11yChecker) report(path, rule string, severity Severity, format string, a ...interface{}) {
+	c.issues = append(c.issues, A11yIssue{
+		Path:     path,
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (c *a11yChecker) element(n *RawNode, ancestors []*RawNode, path string) bool {
+	switch n.tag {
+	case "html":
+		if strings.TrimSpace(n.attr("lang")) == "" {
+			c.report(path, "html-lang", SeverityError, "<html> has no lang attribute")
+		}
+	case "img":
+		if !n.hasAttr("alt") {
+			c.report(path, "img-alt", SeverityError, "<img> has no alt attribute")
+		}
+	case "a":
+		if !hasAccessibleName(n) {
+			c.report(path, "link-name", SeverityError, "<a> has no text")
+		}
+	case "button":
+		if !n.hasAttr("type") {
+			c.report(path, "button-type", SeverityWarning, "<button> has no type, so it submits forms")
+		}
+	case "input", "select", "textarea":
+		if needsLabel(n) && !c.hasLabel(n, ancestors) {
+			c.report(path, "label", SeverityError, "<%s> has no label", n.tag)
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.tag[1] - '0')
+		if c.heading > 0 && level > c.heading+1 {
+			c.report(path, "heading-order", SeverityWarning, "<%s> skips heading levels after <h%d>", n.tag, c.heading)
+		}
+		c.heading = level
+	}
+
+	if n.hasAttr("role") {
+		if reason := checkAttr(attr{name: "role", value: n.attr("role")}); reason != "" {
+			c.report(path, "aria-role", SeverityError, "%s", reason)
+		}
+	}
+
+	return true
+}
+
+// needsLabel returns whether the element is a form control that needs a
+// label, rather than one labelled by its value or alt text
+func needsLabel(n *RawNode) bool {
+	if n.tag != "input" {
+		return true
+	}
+
+	switch strings.ToLower(n.attr("type")) {
+	case "hidden", "submit", "reset", "button", "image":
+		return false
+	}
+	return true
+}
+
+func (c *a11yChecker) hasLabel(n *RawNode, ancestors []*RawNode) bool {
+	if hasAriaLabel(n) {
+		return true
+	}
+
+	if id := n.attr("id"); id != "" && c.labelled[id] {
+		return true
+	}
+
+	for _, a := range ancestors {
+		if a.tag == "label" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAriaLabel(n *RawNode) bool {
+	return strings.TrimSpace(n.attr("aria-label")) != "" || strings.TrimSpace(n.attr("aria-labelledby")) != ""
+}
+
+// hasAccessibleName returns whether assistive technology has something to
+// announce for the element, from its ARIA label, its text, the alt text of
+// its images or its title
+func hasAccessibleName(n *RawNode) bool {
+	if hasAriaLabel(n) || strings.TrimSpace(n.attr("title")) != "" {
+		return true
+	}
+
+	for _, c := range visibleChildren(n) {
+		switch {
+		case c.nodeType == textNode:
+			if strings.TrimSpace(c.text) != "" {
+				return true
+			}
+		case c.attr("aria-hidden") == "true":
+		case c.tag == "img":
+			if strings.TrimSpace(c.attr("alt")) != "" {
+				return true
+			}
+		case hasAccessibleName(c):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package hagl_test
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestCheckA11y(t *testing.T) {
+	messages := func(node Node) []string {
+		var msgs []string
+		for _, issue := range CheckA11y(node) {
+			msgs = append(msgs, issue.Error())
+		}
+		return msgs
+	}
+
+	t.Run("accepts accessible markup", func(t *testing.T) {
+		root := Document(DocumentOptions{Lang: "en", Title: "Sign up"}).Children(
+			H1().Text("Sign up"),
+			A().Href("/").Children(Img().Src("/logo.png").Alt("Home")),
+			A().Href("/help").Aria(ARIALabel, "Help").Children(Span().Class("icon")),
+			Img().Src("/divider.png").Alt(""),
+			H2().Text("Account"),
+			Form().Children(
+				Label().Attr("for", "email").Text("Email"),
+				Input().ID("email").Type("email"),
+				Label().Text("Name ").Children(Input().Type("text")),
+				Select().Aria(ARIALabel, "Plan"),
+				Input().Type("hidden").Name("token"),
+				Input().Type("submit").Value("Sign up"),
+				Button().Type("button").Role(RoleSwitch).Text("Remember me"),
+			),
+			H2().Text("Help"),
+			H3().Text("Contact"),
+		)
+		assert.Empty(t, messages(root))
+	})
+
+	t.Run("reports issues", func(t *testing.T) {
+		root := Document(DocumentOptions{}).Children(
+			H1().Text("Title"),
+			H3().Text("Skipped"),
+			Img().Src("/photo.png"),
+			A().Href("/").Children(Span().Aria(ARIAHidden, "true").Text("→")),
+			Form().Children(
+				Label().Text("Email"),
+				Input().Type("email"),
+				Textarea(),
+				Button().Text("Send"),
+			),
+			Div().Role("buton"),
+		)
+		assert.Equal(t, []string{
+			"error: html: <html> has no lang attribute (html-lang)",
+			"warning: html > body > h3: <h3> skips heading levels after <h1> (heading-order)",
+			"error: html > body > img: <img> has no alt attribute (img-alt)",
+			"error: html > body > a: <a> has no text (link-name)",
+			"error: html > body > form > input: <input> has no label (label)",
+			"error: html > body > form > textarea: <textarea> has no label (label)",
+			"warning: html > body > form > button: <button> has no type, so it submits forms (button-type)",
+			`error: html > body > div: unknown role "buton" (aria-role)`,
+		}, messages(root))
+	})
+
+	t.Run("includes severity and rule", func(t *testing.T) {
+		issues := CheckA11y(Div().Children(Img()))
+		assert.Equal(t, []A11yIssue{{
+			Path:     "div > img",
+			Rule:     "img-alt",
+			Severity: SeverityError,
+			Message:  "<img> has no alt attribute",
+		}}, issues)
+	})
+}
@@ -0,0 +1,121 @@
+package hagl
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ANSIOptions configures the terminal output of ToANSI
+type ANSIOptions struct {
+	TextOptions
+
+	// NoColor disables all escape sequences, which makes the output the
+	// same as ToTextWith
+	NoColor bool
+}
+
+// DefaultANSIOptions returns options that wrap text to the width of the
+// terminal and disable colors when the NO_COLOR environment variable is set
+func DefaultANSIOptions() ANSIOptions {
+	opts := ANSIOptions{
+		TextOptions: DefaultTextOptions(),
+		NoColor:     os.Getenv("NO_COLOR") != "",
+	}
+
+	opts.LineWidth = TerminalWidth()
+	return opts
+}
+
+// TerminalWidth returns the width of the terminal from the COLUMNS
+// environment variable, or from the terminal that stdout or stderr is
+// connected to, or 80 if neither is known. The terminal is only queried on
+// Unix systems.
+func TerminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	if columns := ttyWidth(); columns > 0 {
+		return columns
+	}
+
+	return DefaultTextOptions().LineWidth
+}
+
+const (
+	ansiBold        = "\x1b[1m"
+	ansiNormal      = "\x1b[22m"
+	ansiItalic      = "\x1b[3m"
+	ansiNoItalic    = "\x1b[23m"
+	ansiUnderline   = "\x1b[4m"
+	ansiNoUnderline = "\x1b[24m"
+	ansiCyan        = "\x1b[36m"
+	ansiNoColor     = "\x1b[39m"
+)
+
+// style returns the escape sequences that start and end the styling of n
+// in terminal output. Links become OSC 8 hyperlinks.
+func (r *textRenderer) style(n *RawNode) (string, string) {
+	if !r.ansi {
+		return "", ""
+	}
+
+	switch n.tag {
+	case "strong", "b", "h1", "h2", "h3", "h4", "h5", "h6":
+		return ansiBold, ansiNormal
+	case "em", "i":
+		return ansiItalic, ansiNoItalic
+	case "u":
+		return ansiUnderline, ansiNoUnderline
+	case "code", "kbd", "samp", "pre":
+		return ansiCyan, ansiNoColor
+	case "a":
+		if href := n.attr("href"); href != "" {
+			return "\x1b]8;;" + stripControl(href, false) + "\x1b\\", "\x1b]8;;\x1b\\"
+		}
+	}
+
+	return "", ""
+}
+
+// plain removes the control characters from text written to the terminal,
+// so content can't start escape sequences of its own
+func (r *textRenderer) plain(s string) string {
+	if !r.ansi {
+		return s
+	}
+	return stripControl(s, true)
+}
+
+// stripControl removes C0 and C1 control characters from s, keeping
+// newlines and tabs if keepSpace is set
+func stripControl(s string, keepSpace bool) string {
+	return strings.Map(func(c rune) rune {
+		if keepSpace && (c == '\n' || c == '\t') {
+			return c
+		}
+		if c < ' ' || c >= 0x7f && c <= 0x9f {
+			return -1
+		}
+		return c
+	}, s)
+}
+
+// styleLines styles each line of a block separately so prefixes added to
+// the lines later, like list markers, aren't styled
+func (r *textRenderer) styleLines(n *RawNode, text string) string {
+	start, end := r.style(n)
+	if start == "" {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = start + line + end
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package hagl
+
+// ttyWidth isn't supported on this platform, so the terminal width comes
+// from COLUMNS alone
+func ttyWidth() int {
+	return 0
+}
@@ -0,0 +1,85 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestElement_ToANSI(t *testing.T) {
+	t.Run("styles inline elements", func(t *testing.T) {
+		opts := ANSIOptions{TextOptions: DefaultTextOptions()}
+
+		root := P().Children(
+			Strong().Text("bold"),
+			Text(" "),
+			Em().Text("italic"),
+			Text(" "),
+			U().Text("underline"),
+			Text(" "),
+			Code().Text("code"),
+		)
+		assert.Equal(t,
+			"\x1b[1mbold\x1b[22m \x1b[3mitalic\x1b[23m \x1b[4munderline\x1b[24m \x1b[36mcode\x1b[39m",
+			root.ToANSI(opts),
+		)
+	})
+
+	t.Run("styles headings and links", func(t *testing.T) {
+		opts := ANSIOptions{TextOptions: DefaultTextOptions()}
+		opts.Links = LinkHidden
+
+		root := Div().Children(
+			H1().Text("Title"),
+			P().Children(A().Href("https://yaak.app").Text("Yaak")),
+		)
+		assert.Equal(t,
+			"\x1b[1mTitle\x1b[22m\n\n\x1b]8;;https://yaak.app\x1b\\Yaak\x1b]8;;\x1b\\",
+			root.ToANSI(opts),
+		)
+	})
+
+	t.Run("wraps without counting escape sequences", func(t *testing.T) {
+		opts := ANSIOptions{TextOptions: DefaultTextOptions()}
+		opts.LineWidth = 10
+
+		root := P().Children(Strong().Text("one two"), Text(" three"))
+		assert.Equal(t, "\x1b[1mone two\x1b[22m\nthree", root.ToANSI(opts))
+	})
+
+	t.Run("disables colors", func(t *testing.T) {
+		opts := ANSIOptions{TextOptions: DefaultTextOptions(), NoColor: true}
+
+		root := Div().Children(
+			H1().Text("Title"),
+			P().Children(Strong().Text("bold"), A().Href("https://yaak.app").Text(" link")),
+		)
+		assert.Equal(t, root.ToText(), root.ToANSI(opts))
+		assert.False(t, strings.Contains(root.ToANSI(opts), "\x1b"))
+	})
+
+	t.Run("strips control characters", func(t *testing.T) {
+		opts := ANSIOptions{TextOptions: DefaultTextOptions()}
+		opts.Links = LinkHidden
+
+		root := Div().Children(
+			P().Children(A().Href("https://yaak.app\x1b\\\x07").Text("Ya\x1b[2Jak\u009b")),
+			Pre().Text("one\x1b]0;title\x07\n\ttwo"),
+		)
+		assert.Equal(t,
+			"\x1b]8;;https://yaak.app\\\x1b\\Ya[2Jak\x1b]8;;\x1b\\\n\n\x1b[36mone]0;title\x1b[39m\n\x1b[36m\ttwo\x1b[39m",
+			root.ToANSI(opts),
+		)
+	})
+
+	t.Run("uses terminal width", func(t *testing.T) {
+		t.Setenv("COLUMNS", "42")
+		t.Setenv("NO_COLOR", "1")
+
+		opts := DefaultANSIOptions()
+		assert.Equal(t, 42, opts.LineWidth)
+		assert.True(t, opts.NoColor)
+	})
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package hagl
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ttyWidth returns the number of columns of the terminal that stdout, or
+// failing that stderr, is connected to, or 0 if neither is a terminal
+func ttyWidth() int {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		var size struct{ rows, cols, x, y uint16 }
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+		if errno == 0 && size.cols > 0 {
+			return int(size.cols)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,452 @@
+package hagl
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ARIA is the name of an ARIA attribute, without the "aria-" prefix, for use
+// with Aria
+type ARIA string
+
+const (
+	ARIAActiveDescendant ARIA = "activedescendant"
+	ARIAAtomic           ARIA = "atomic"
+	ARIAAutocomplete     ARIA = "autocomplete"
+	ARIABusy             ARIA = "busy"
+	ARIAChecked          ARIA = "checked"
+	ARIAColCount         ARIA = "colcount"
+	ARIAColIndex         ARIA = "colindex"
+	ARIAColSpan          ARIA = "colspan"
+	ARIAControls         ARIA = "controls"
+	ARIACurrent          ARIA = "current"
+	ARIADescribedBy      ARIA = "describedby"
+	ARIADescription      ARIA = "description"
+	ARIADetails          ARIA = "details"
+	ARIADisabled         ARIA = "disabled"
+	ARIAErrorMessage     ARIA = "errormessage"
+	ARIAExpanded         ARIA = "expanded"
+	ARIAFlowTo           ARIA = "flowto"
+	ARIAHasPopup         ARIA = "haspopup"
+	ARIAHidden           ARIA = "hidden"
+	ARIAInvalid          ARIA = "invalid"
+	ARIAKeyShortcuts     ARIA = "keyshortcuts"
+	ARIALabel            ARIA = "label"
+	ARIALabelledBy       ARIA = "labelledby"
+	ARIALevel            ARIA = "level"
+	ARIALive             ARIA = "live"
+	ARIAModal            ARIA = "modal"
+	ARIAMultiline        ARIA = "multiline"
+	ARIAMultiselectable  ARIA = "multiselectable"
+	ARIAOrientation      ARIA = "orientation"
+	ARIAOwns             ARIA = "owns"
+	ARIAPlaceholder      ARIA = "placeholder"
+	ARIAPosInSet         ARIA = "posinset"
+	ARIAPressed          ARIA = "pressed"
+	ARIAReadOnly         ARIA = "readonly"
+	ARIARelevant         ARIA = "relevant"
+	ARIARequired         ARIA = "required"
+	ARIARoleDescription  ARIA = "roledescription"
+	ARIARowCount         ARIA = "rowcount"
+	ARIARowIndex         ARIA = "rowindex"
+	ARIARowSpan          ARIA = "rowspan"
+	ARIASelected         ARIA = "selected"
+	ARIASetSize          ARIA = "setsize"
+	ARIASort             ARIA = "sort"
+	ARIAValueMax         ARIA = "valuemax"
+	ARIAValueMin         ARIA = "valuemin"
+	ARIAValueNow         ARIA = "valuenow"
+	ARIAValueText        ARIA = "valuetext"
+)
+
+// ariaKind is the type of value an ARIA attribute accepts
+type ariaKind int
+
+const (
+	ariaString ariaKind = iota
+	ariaToken
+	ariaTokenList
+	ariaInteger
+	ariaNumber
+	ariaIDRef
+	ariaIDRefList
+)
+
+type ariaDef struct {
+	kind   ariaKind
+	tokens []string
+}
+
+var (
+	ariaBool      = ariaDef{kind: ariaToken, tokens: []string{"true", "false"}}
+	ariaUndefined = ariaDef{kind: ariaToken, tokens: []string{"true", "false", "undefined"}}
+	ariaTristate  = ariaDef{kind: ariaToken, tokens: []string{"true", "false", "mixed", "undefined"}}
+)
+
+// ariaDefs are the values accepted by each ARIA attribute, from WAI-ARIA 1.2
+var ariaDefs = map[ARIA]ariaDef{
+	ARIAActiveDescendant: {kind: ariaIDRef},
+	ARIAAtomic:           ariaBool,
+	ARIAAutocomplete:     {kind: ariaToken, tokens: []string{"inline", "list", "both", "none"}},
+	ARIABusy:             ariaBool,
+	ARIAChecked:          ariaTristate,
+	ARIAColCount:         {kind: ariaInteger},
+	ARIAColIndex:         {kind: ariaInteger},
+	ARIAColSpan:          {kind: ariaInteger},
+	ARIAControls:         {kind: ariaIDRefList},
+	ARIACurrent:          {kind: ariaToken, tokens: []string{"page", "step", "location", "date", "time", "true", "false"}},
+	ARIADescribedBy:      {kind: ariaIDRefList},
+	ARIADescription:      {kind: ariaString},
+	ARIADetails:          {kind: ariaIDRefList},
+	ARIADisabled:         ariaBool,
+	ARIAErrorMessage:     {kind: ariaIDRefList},
+	ARIAExpanded:         ariaUndefined,
+	ARIAFlowTo:           {kind: ariaIDRefList},
+	ARIAHasPopup:         {kind: ariaToken, tokens: []string{"false", "true", "menu", "listbox", "tree", "grid", "dialog"}},
+	ARIAHidden:           ariaUndefined,
+	ARIAInvalid:          {kind: ariaToken, tokens: []string{"grammar", "false", "spelling", "true"}},
+	ARIAKeyShortcuts:     {kind: ariaString},
+	ARIALabel:            {kind: ariaString},
+	ARIALabelledBy:       {kind: ariaIDRefList},
+	ARIALevel:            {kind: ariaInteger},
+	ARIALive:             {kind: ariaToken, tokens: []string{"assertive", "off", "polite"}},
+	ARIAModal:            ariaBool,
+	ARIAMultiline:        ariaBool,
+	ARIAMultiselectable:  ariaBool,
+	ARIAOrientation:      {kind: ariaToken, tokens: []string{"horizontal", "undefined", "vertical"}},
+	ARIAOwns:             {kind: ariaIDRefList},
+	ARIAPlaceholder:      {kind: ariaString},
+	ARIAPosInSet:         {kind: ariaInteger},
+	ARIAPressed:          ariaTristate,
+	ARIAReadOnly:         ariaBool,
+	ARIARelevant:         {kind: ariaTokenList, tokens: []string{"additions", "all", "removals", "text"}},
+	ARIARequired:         ariaBool,
+	ARIARoleDescription:  {kind: ariaString},
+	ARIARowCount:         {kind: ariaInteger},
+	ARIARowIndex:         {kind: ariaInteger},
+	ARIARowSpan:          {kind: ariaInteger},
+	ARIASelected:         ariaUndefined,
+	ARIASetSize:          {kind: ariaInteger},
+	ARIASort:             {kind: ariaToken, tokens: []string{"ascending", "descending", "none", "other"}},
+	ARIAValueMax:         {kind: ariaNumber},
+	ARIAValueMin:         {kind: ariaNumber},
+	ARIAValueNow:         {kind: ariaNumber},
+	ARIAValueText:        {kind: ariaString},
+}
+
+// check returns why the value isn't valid for the attribute, or "" if it is
+func (d ariaDef) check(value string) string {
+	switch d.kind {
+	case ariaToken:
+		if !slices.Contains(d.tokens, value) {
+			return "must be one of " + strings.Join(d.tokens, ", ")
+		}
+	case ariaTokenList:
+		tokens := strings.Fields(value)
+		if len(tokens) == 0 {
+			return "must be a list of " + strings.Join(d.tokens, ", ")
+		}
+		for _, t := range tokens {
+			if !slices.Contains(d.tokens, t) {
+				return "must be a list of " + strings.Join(d.tokens, ", ")
+			}
+		}
+	case ariaInteger:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "must be an integer"
+		}
+	case ariaNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "must be a number"
+		}
+	case ariaIDRef:
+		if len(strings.Fields(value)) != 1 {
+			return "must be an ID"
+		}
+	case ariaIDRefList:
+		if len(strings.Fields(value)) == 0 {
+			return "must be a list of IDs"
+		}
+	}
+	return ""
+}
+
+// Role is an ARIA role, for use with the role attribute
+type Role string
+
+const (
+	RoleAlert            Role = "alert"
+	RoleAlertDialog      Role = "alertdialog"
+	RoleApplication      Role = "application"
+	RoleArticle          Role = "article"
+	RoleBanner           Role = "banner"
+	RoleBlockquote       Role = "blockquote"
+	RoleButton           Role = "button"
+	RoleCaption          Role = "caption"
+	RoleCell             Role = "cell"
+	RoleCheckbox         Role = "checkbox"
+	RoleCode             Role = "code"
+	RoleColumnHeader     Role = "columnheader"
+	RoleCombobox         Role = "combobox"
+	RoleComplementary    Role = "complementary"
+	RoleContentInfo      Role = "contentinfo"
+	RoleDefinition       Role = "definition"
+	RoleDeletion         Role = "deletion"
+	RoleDialog           Role = "dialog"
+	RoleDocument         Role = "document"
+	RoleEmphasis         Role = "emphasis"
+	RoleFeed             Role = "feed"
+	RoleFigure           Role = "figure"
+	RoleForm             Role = "form"
+	RoleGeneric          Role = "generic"
+	RoleGrid             Role = "grid"
+	RoleGridCell         Role = "gridcell"
+	RoleGroup            Role = "group"
+	RoleHeading          Role = "heading"
+	RoleImg              Role = "img"
+	RoleInsertion        Role = "insertion"
+	RoleLink             Role = "link"
+	RoleList             Role = "list"
+	RoleListbox          Role = "listbox"
+	RoleListItem         Role = "listitem"
+	RoleLog              Role = "log"
+	RoleMain             Role = "main"
+	RoleMark             Role = "mark"
+	RoleMarquee          Role = "marquee"
+	RoleMath             Role = "math"
+	RoleMenu             Role = "menu"
+	RoleMenubar          Role = "menubar"
+	RoleMenuItem         Role = "menuitem"
+	RoleMenuItemCheckbox Role = "menuitemcheckbox"
+	RoleMenuItemRadio    Role = "menuitemradio"
+	RoleMeter            Role = "meter"
+	RoleNavigation       Role = "navigation"
+	RoleNone             Role = "none"
+	RoleNote             Role = "note"
+	RoleOption           Role = "option"
+	RoleParagraph        Role = "paragraph"
+	RolePresentation     Role = "presentation"
+	RoleProgressbar      Role = "progressbar"
+	RoleRadio            Role = "radio"
+	RoleRadioGroup       Role = "radiogroup"
+	RoleRegion           Role = "region"
+	RoleRow              Role = "row"
+	RoleRowGroup         Role = "rowgroup"
+	RoleRowHeader        Role = "rowheader"
+	RoleScrollbar        Role = "scrollbar"
+	RoleSearch           Role = "search"
+	RoleSearchbox        Role = "searchbox"
+	RoleSeparator        Role = "separator"
+	RoleSlider           Role = "slider"
+	RoleSpinButton       Role = "spinbutton"
+	RoleStatus           Role = "status"
+	RoleStrong           Role = "strong"
+	RoleSubscript        Role = "subscript"
+	RoleSuperscript      Role = "superscript"
+	RoleSwitch           Role = "switch"
+	RoleTab              Role = "tab"
+	RoleTable            Role = "table"
+	RoleTabList          Role = "tablist"
+	RoleTabPanel         Role = "tabpanel"
+	RoleTerm             Role = "term"
+	RoleTextbox          Role = "textbox"
+	RoleTime             Role = "time"
+	RoleTimer            Role = "timer"
+	RoleToolbar          Role = "toolbar"
+	RoleTooltip          Role = "tooltip"
+	RoleTree             Role = "tree"
+	RoleTreeGrid         Role = "treegrid"
+	RoleTreeItem         Role = "treeitem"
+)
+
+var roles = []Role{
+	RoleAlert, RoleAlertDialog, RoleApplication, RoleArticle, RoleBanner,
+	RoleBlockquote, RoleButton, RoleCaption, RoleCell, RoleCheckbox, RoleCode,
+	RoleColumnHeader, RoleCombobox, RoleComplementary, RoleContentInfo,
+	RoleDefinition, RoleDeletion, RoleDialog, RoleDocument, RoleEmphasis,
+	RoleFeed, RoleFigure, RoleForm, RoleGeneric, RoleGrid, RoleGridCell,
+	RoleGroup, RoleHeading, RoleImg, RoleInsertion, RoleLink, RoleList,
+	RoleListbox, RoleListItem, RoleLog, RoleMain, RoleMark, RoleMarquee,
+	RoleMath, RoleMenu, RoleMenubar, RoleMenuItem, RoleMenuItemCheckbox,
+	RoleMenuItemRadio, RoleMeter, RoleNavigation, RoleNone, RoleNote,
+	RoleOption, RoleParagraph, RolePresentation, RoleProgressbar, RoleRadio,
+	RoleRadioGroup, RoleRegion, RoleRow, RoleRowGroup, RoleRowHeader,
+	RoleScrollbar, RoleSearch, RoleSearchbox, RoleSeparator, RoleSlider,
+	RoleSpinButton, RoleStatus, RoleStrong, RoleSubscript, RoleSuperscript,
+	RoleSwitch, RoleTab, RoleTable, RoleTabList, RoleTabPanel, RoleTerm,
+	RoleTextbox, RoleTime, RoleTimer, RoleToolbar, RoleTooltip, RoleTree,
+	RoleTreeGrid, RoleTreeItem,
+}
+
+// Event is the name of a DOM event, without the "on" prefix, for use with On
+type Event string
+
+const (
+	EventBlur       Event = "blur"
+	EventChange     Event = "change"
+	EventClick      Event = "click"
+	EventDblClick   Event = "dblclick"
+	EventError      Event = "error"
+	EventFocus      Event = "focus"
+	EventInput      Event = "input"
+	EventKeyDown    Event = "keydown"
+	EventKeyUp      Event = "keyup"
+	EventLoad       Event = "load"
+	EventMouseEnter Event = "mouseenter"
+	EventMouseLeave Event = "mouseleave"
+	EventReset      Event = "reset"
+	EventScroll     Event = "scroll"
+	EventSubmit     Event = "submit"
+	EventToggle     Event = "toggle"
+)
+
+// events are the events that have an event handler attribute
+var events = []Event{
+	"abort", "afterprint", "animationend", "animationiteration",
+	"animationstart", "auxclick", "beforeinput", "beforeprint",
+	"beforetoggle", "beforeunload", "blur", "cancel", "canplay",
+	"canplaythrough", "change", "click", "close", "contextlost", "contextmenu",
+	"contextrestored", "copy", "cuechange", "cut", "dblclick", "drag",
+	"dragend", "dragenter", "dragleave", "dragover", "dragstart", "drop",
+	"durationchange", "emptied", "ended", "error", "focus", "focusin",
+	"focusout", "formdata", "hashchange", "input", "invalid", "keydown",
+	"keypress", "keyup", "languagechange", "load", "loadeddata",
+	"loadedmetadata", "loadstart", "message", "messageerror", "mousedown",
+	"mouseenter", "mouseleave", "mousemove", "mouseout", "mouseover",
+	"mouseup", "offline", "online", "pagehide", "pageshow", "paste", "pause",
+	"play", "playing", "pointercancel", "pointerdown", "pointerenter",
+	"pointerleave", "pointermove", "pointerout", "pointerover", "pointerup",
+	"popstate", "progress", "ratechange", "rejectionhandled", "reset",
+	"resize", "scroll", "scrollend", "securitypolicyviolation", "seeked",
+	"seeking", "select", "slotchange", "stalled", "storage", "submit",
+	"suspend", "timeupdate", "toggle", "touchcancel", "touchend", "touchmove",
+	"touchstart", "transitionend", "unhandledrejection", "unload",
+	"volumechange", "waiting", "wheel",
+}
+
+// Data sets a data-* attribute. Keys are written like in HTML, as in
+// "user-id", or like the dataset property in JavaScript, as in "userId",
+// which is converted to "user-id".
+func (rn *RawNode) Data(key, value string) Node {
+	return rn.Attr("data-"+dataAttrKey(key), value)
+}
+
+// Aria sets an aria-* attribute, like Aria(ARIALabel, "Close")
+func (rn *RawNode) Aria(key ARIA, value string) Node {
+	return rn.Attr("aria-"+strings.TrimPrefix(string(key), "aria-"), value)
+}
+
+// Role sets the ARIA role of the element
+func (rn *RawNode) Role(role Role) Node {
+	return rn.Attr("role", string(role))
+}
+
+// On sets the event handler attribute for an event, like
+// On(EventClick, "toggle(this)")
+func (rn *RawNode) On(event Event, script string) Node {
+	return rn.Attr("on"+strings.TrimPrefix(string(event), "on"), script)
+}
+
+// dataAttrKey converts the uppercase letters in a key to a dash followed by
+// the lowercase letter, the way the dataset property maps keys to names
+func dataAttrKey(key string) string {
+	var b strings.Builder
+	for _, c := range key {
+		if c >= 'A' && c <= 'Z' {
+			b.WriteByte('-')
+			c += 'a' - 'A'
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// AttrError is an invalid attribute, as reported by CheckAttrs
+type AttrError struct {
+	// Tag is the element the attribute is on
+	Tag   string
+	Name  string
+	Value string
+
+	// Reason is what's wrong with the attribute
+	Reason string
+}
+
+func (e *AttrError) Error() string {
+	return fmt.Sprintf("<%s %s=%q>: %s", e.Tag, e.Name, e.Value, e.Reason)
+}
+
+// CheckAttrs returns an error for each invalid attribute in the tree, in the
+// order they're rendered. That includes attribute names that would be
+// rewritten when rendering, unknown aria-* attributes, roles and event
+// handlers, and ARIA values of the wrong type.
+func CheckAttrs(node Node) []*AttrError {
+	var errs []*AttrError
+
+	var walk func(n *RawNode)
+	walk = func(n *RawNode) {
+		if n.hide {
+			return
+		}
+
+		if n.nodeType == tagNode {
+			for _, a := range n.attrs {
+				if reason := checkAttr(a); reason != "" {
+					errs = append(errs, &AttrError{Tag: n.tag, Name: a.name, Value: a.value, Reason: reason})
+				}
+			}
+		}
+
+		for _, c := range n.children {
+			walk(c.GetNode())
+		}
+	}
+	walk(node.GetNode())
+
+	return errs
+}
+
+// checkAttr returns why the attribute isn't valid, or "" if it is
+func checkAttr(a attr) string {
+	if a.name == "" || sanitizeAttrName(a.name) != a.name {
+		return "invalid attribute name"
+	}
+
+	name := strings.ToLower(a.name)
+	switch {
+	case strings.HasPrefix(name, "data-"):
+		// Data attribute names have to be XML-compatible, and uppercase
+		// letters would be lowercased by the browser
+		key := strings.TrimPrefix(a.name, "data-")
+		if key == "" || strings.ContainsAny(key, ":ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+			return "invalid data attribute name"
+		}
+	case strings.HasPrefix(name, "aria-"):
+		def, ok := ariaDefs[ARIA(strings.TrimPrefix(name, "aria-"))]
+		if !ok {
+			return "unknown ARIA attribute"
+		}
+		if reason := def.check(strings.TrimSpace(a.value)); reason != "" {
+			return "value " + reason
+		}
+	case name == "role":
+		// Multiple roles can be given, where the first supported one is used
+		tokens := strings.Fields(a.value)
+		if len(tokens) == 0 {
+			return "role is empty"
+		}
+		for _, t := range tokens {
+			if !slices.Contains(roles, Role(t)) {
+				return "unknown role " + strconv.Quote(t)
+			}
+		}
+	case strings.HasPrefix(name, "on"):
+		if !slices.Contains(events, Event(strings.TrimPrefix(name, "on"))) {
+			return "unknown event handler"
+		}
+	}
+
+	return ""
+}
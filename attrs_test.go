@@ -0,0 +1,76 @@
+package hagl_test
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestAttrs(t *testing.T) {
+	t.Run("adds attributes", func(t *testing.T) {
+		root := Button().
+			Data("userId", "1").
+			Data("user.name", "jo").
+			Aria(ARIAExpanded, "false").
+			Aria("aria-controls", "menu").
+			Role(RoleSwitch).
+			On(EventClick, "toggle(this)").
+			Text("Menu")
+		assert.Equal(t, `<button data-user-id="1" data-user.name="jo" aria-expanded="false" aria-controls="menu" role="switch" onclick="toggle(this)">Menu</button>`, root.ToHTML())
+	})
+
+	t.Run("adds attributes to components", func(t *testing.T) {
+		icon := NewComponent(func(children []Node) Node {
+			return Span().Class("icon")
+		})
+		assert.Equal(t, `<span class="icon" aria-hidden="true" role="img"></span>`, icon().Aria(ARIAHidden, "true").Role(RoleImg).ToHTML())
+	})
+
+	t.Run("keeps valid names", func(t *testing.T) {
+		root := Div().Attr("data-user.id", "1").Attr("xml:lang", "en").Attr("@click", "open = true")
+		assert.Equal(t, `<div data-user.id="1" xml:lang="en" @click="open = true"></div>`, root.ToHTML())
+	})
+
+	t.Run("checks valid attributes", func(t *testing.T) {
+		root := Div().Data("id", "1").Aria(ARIALive, "polite").Role("tab button").Children(
+			Input().Aria(ARIAValueNow, "1.5").Aria(ARIALabelledBy, "a b").On(EventInput, "save()"),
+		)
+		assert.Empty(t, CheckAttrs(root))
+
+		html, err := StrictHTML(root)
+		assert.NoError(t, err)
+		assert.Equal(t, root.ToHTML(), html)
+	})
+
+	t.Run("reports invalid attributes", func(t *testing.T) {
+		root := Div().Attr("data-user id", "1").Children(
+			Span().Attr("data-userId", "1"),
+			Span().Aria("labeled", "x"),
+			Span().Aria(ARIAExpanded, "yes"),
+			Span().Aria(ARIALevel, "two"),
+			Span().Role("buton"),
+			Span().On("clik", "go()"),
+			Span().Attr("onclik", "go()").If(false),
+		)
+
+		var msgs []string
+		for _, err := range CheckAttrs(root) {
+			msgs = append(msgs, err.Error())
+		}
+		assert.Equal(t, []string{
+			`<div data-user id="1">: invalid attribute name`,
+			`<span data-userId="1">: invalid data attribute name`,
+			`<span aria-labeled="x">: unknown ARIA attribute`,
+			`<span aria-expanded="yes">: value must be one of true, false, undefined`,
+			`<span aria-level="two">: value must be an integer`,
+			`<span role="buton">: unknown role "buton"`,
+			`<span onclik="go()">: unknown event handler`,
+		}, msgs)
+
+		_, err := StrictHTML(root)
+		assert.ErrorContains(t, err, `div: data-user id="1": invalid attribute name`)
+		assert.ErrorContains(t, err, `div > span[6]: onclik="go()": unknown event handler`)
+	})
+}
@@ -85,6 +85,11 @@ func (c *component) StyleProperty(name, value string) Node {
 	return c
 }
 
+func (c *component) Styles(s *Styles) Node {
+	c.base.Styles(s)
+	return c
+}
+
 func (c *component) Href(value string) Node {
 	c.base.Href(value)
 	return c
@@ -140,6 +145,56 @@ func (c *component) Title(value string) Node {
 	return c
 }
 
+func (c *component) HxGet(url string) Node {
+	c.base.HxGet(url)
+	return c
+}
+
+func (c *component) HxPost(url string) Node {
+	c.base.HxPost(url)
+	return c
+}
+
+func (c *component) HxTarget(selector string) Node {
+	c.base.HxTarget(selector)
+	return c
+}
+
+func (c *component) HxSwap(swap Swap) Node {
+	c.base.HxSwap(swap)
+	return c
+}
+
+func (c *component) HxTrigger(trigger string) Node {
+	c.base.HxTrigger(trigger)
+	return c
+}
+
+func (c *component) HxSwapOOB(swap Swap) Node {
+	c.base.HxSwapOOB(swap)
+	return c
+}
+
+func (c *component) Data(key, value string) Node {
+	c.base.Data(key, value)
+	return c
+}
+
+func (c *component) Aria(key ARIA, value string) Node {
+	c.base.Aria(key, value)
+	return c
+}
+
+func (c *component) Role(role Role) Node {
+	c.base.Role(role)
+	return c
+}
+
+func (c *component) On(event Event, script string) Node {
+	c.base.On(event, script)
+	return c
+}
+
 func (c *component) If(b bool) Node {
 	c.base.If(b)
 	return c
@@ -171,10 +226,30 @@ func (c *component) ToText() string {
 	return c.merge().ToText()
 }
 
+func (c *component) ToTextWith(opts TextOptions) string {
+	return c.merge().ToTextWith(opts)
+}
+
+func (c *component) ToMarkdown() string {
+	return c.merge().ToMarkdown()
+}
+
+func (c *component) ToANSI(opts ANSIOptions) string {
+	return c.merge().ToANSI(opts)
+}
+
 func (c *component) ToHTMLPretty() string {
 	return c.merge().ToHTMLPretty()
 }
 
+func (c *component) ToHTMLPrettyWith(opts PrettyOptions) string {
+	return c.merge().ToHTMLPrettyWith(opts)
+}
+
+func (c *component) ToHTMLMinified(opts MinifyOptions) string {
+	return c.merge().ToHTMLMinified(opts)
+}
+
 func (c *component) Write(w io.Writer) (int, error) {
 	return c.merge().Write(w)
 }
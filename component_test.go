@@ -51,4 +51,13 @@ func TestComponent(t *testing.T) {
 			Btn().Type("submit").Text("Submit").ToHTML(),
 		)
 	})
+
+	t.Run("extends with text without escaping it twice", func(t *testing.T) {
+		Label := NewComponent(func(children []Node) Node {
+			return Span().Class("label").Children(children...)
+		})
+
+		assert.Equal(t, `<span class="label">Tom &amp; Jerry</span>`, Label().Extend(Text("Tom & Jerry")).ToHTML())
+		assert.Equal(t, `<span class="label"><b>Bold</b></span>`, Label().Extend(UnsafeText("<b>Bold</b>")).ToHTML())
+	})
 }
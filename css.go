@@ -0,0 +1,274 @@
+package hagl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// cssRule is a rule in a stylesheet, like "h1 { color: red }". For at-rules
+// like @media, the block contains nested rules. Statements like @import
+// have no block.
+type cssRule struct {
+	prelude  string
+	block    string
+	hasBlock bool
+}
+
+func (r cssRule) String() string {
+	if !r.hasBlock {
+		return r.prelude + ";"
+	}
+	return r.prelude + " { " + r.block + " }"
+}
+
+// parseCSSRules splits a stylesheet into its top-level rules, dropping
+// comments. The rules that could be parsed are returned along with an error
+// if the braces aren't balanced.
+func parseCSSRules(css string) ([]cssRule, error) {
+	var (
+		rules []cssRule
+		start = 0
+		depth = 0
+		open  = 0
+	)
+
+	var err error
+	css = stripCSSComments(css)
+	for i := 0; i < len(css); i++ {
+		switch c := css[i]; c {
+		case '"', '\'':
+			i = skipCSSString(css, i)
+		case '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				// Skip unbalanced braces
+				if err == nil {
+					err = fmt.Errorf("unexpected } at %q", cssContext(css, i))
+				}
+				start = i + 1
+				continue
+			}
+
+			depth--
+			if depth == 0 {
+				rules = append(rules, cssRule{
+					prelude:  strings.TrimSpace(css[start:open]),
+					block:    strings.TrimSpace(css[open+1 : i]),
+					hasBlock: true,
+				})
+				start = i + 1
+			}
+		case ';':
+			if depth == 0 {
+				if prelude := strings.TrimSpace(css[start:i]); prelude != "" {
+					rules = append(rules, cssRule{prelude: prelude})
+				}
+				start = i + 1
+			}
+		}
+	}
+
+	if depth > 0 && err == nil {
+		err = fmt.Errorf("unclosed block at %q", cssContext(css, open))
+	}
+
+	return rules, err
+}
+
+// cssContext returns the CSS around i, for error messages
+func cssContext(css string, i int) string {
+	start, end := maxInt(i-20, 0), minInt(i+20, len(css))
+	return strings.Join(strings.Fields(css[start:end]), " ")
+}
+
+// skipCSSString returns the index of the quote that closes the string
+// starting at i
+func skipCSSString(css string, i int) int {
+	quote := css[i]
+	for i++; i < len(css); i++ {
+		if css[i] == '\\' {
+			i++
+		} else if css[i] == quote {
+			return i
+		}
+	}
+	return len(css)
+}
+
+func stripCSSComments(css string) string {
+	var b strings.Builder
+	for i := 0; i < len(css); i++ {
+		switch {
+		case css[i] == '"' || css[i] == '\'':
+			end := minInt(skipCSSString(css, i), len(css)-1)
+			b.WriteString(css[i : end+1])
+			i = end
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+		default:
+			b.WriteByte(css[i])
+		}
+	}
+	return b.String()
+}
+
+// splitCSSList splits s at commas that aren't inside parentheses, brackets
+// or strings, like the selectors in "a, :is(b, c)"
+func splitCSSList(s string) []string {
+	var (
+		parts []string
+		start = 0
+		depth = 0
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			i = skipCSSString(s, i)
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, strings.TrimSpace(s[minInt(start, len(s)):]))
+}
+
+// cssNestedAtRules are at-rules whose blocks contain other rules
+var cssNestedAtRules = []string{"@media", "@supports", "@container", "@layer", "@document"}
+
+// isNestedAtRule returns whether the rule is an at-rule containing other
+// rules, like @media
+func (r cssRule) isNestedAtRule() bool {
+	name := strings.FieldsFunc(r.prelude, func(c rune) bool {
+		return c == '(' || isHTMLSpace(c)
+	})
+	return r.hasBlock && len(name) > 0 && slices.Contains(cssNestedAtRules, strings.ToLower(name[0]))
+}
+
+// cssDeclaration is a property in a rule or style attribute, like
+// "color: red !important"
+type cssDeclaration struct {
+	name      string
+	value     string
+	important bool
+}
+
+func (d cssDeclaration) String() string {
+	if d.important {
+		return d.name + ":" + d.value + " !important"
+	}
+	return d.name + ":" + d.value
+}
+
+// parseCSSDeclarations parses the declarations in a rule block or style
+// attribute, skipping any that are invalid
+func parseCSSDeclarations(block string) []cssDeclaration {
+	var (
+		decls []cssDeclaration
+		start = 0
+		depth = 0
+	)
+
+	block = stripCSSComments(block)
+	add := func(s string) {
+		name, value, ok := strings.Cut(s, ":")
+		if !ok {
+			return
+		}
+
+		if d, ok := newCSSDeclaration(name, value); ok {
+			decls = append(decls, d)
+		}
+	}
+
+	for i := 0; i < len(block); i++ {
+		switch block[i] {
+		case '\\':
+			i++
+		case '"', '\'':
+			i = skipCSSString(block, i)
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ';':
+			if depth <= 0 {
+				add(block[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if start < len(block) {
+		add(block[start:])
+	}
+
+	return decls
+}
+
+// invalidCSSNameChars matches characters that aren't allowed in the names of
+// declarations
+var invalidCSSNameChars = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+
+// newCSSDeclaration creates a declaration from CSS that has already been
+// escaped, reporting whether the name and value are valid
+func newCSSDeclaration(name, value string) (cssDeclaration, bool) {
+	name = strings.TrimSpace(name)
+	if !strings.HasPrefix(name, "--") {
+		name = strings.ToLower(name)
+	}
+
+	value = strings.TrimSpace(value)
+	important := false
+	if i := strings.LastIndex(value, "!"); i >= 0 {
+		if strings.EqualFold(strings.TrimSpace(value[i+1:]), "important") {
+			value = strings.TrimSpace(value[:i])
+			important = true
+		}
+	}
+
+	valid := name != "" && !invalidCSSNameChars.MatchString(name) && value != ""
+	return cssDeclaration{name: name, value: value, important: important}, valid
+}
+
+// escapeCSSValue escapes characters that would end a declaration or rule,
+// so a value can't add declarations of its own. Existing escapes, like
+// "\201C", are kept.
+func escapeCSSValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case ';', '{', '}':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\\':
+			// A backslash can't be allowed to escape the escapes added here
+			if i+1 == len(value) || strings.IndexByte(";{}", value[i+1]) >= 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		case '\n', '\r', '\f':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,342 @@
+package hagl
+
+import (
+	"io"
+)
+
+// DocumentOptions configures Document
+type DocumentOptions struct {
+	// Lang is the language of the document, like "en". It's omitted
+	// when empty.
+	Lang string
+
+	// Dir is the direction of the text in the document, like "ltr" or
+	// "rtl". It's omitted when empty.
+	Dir string
+
+	// Title is the title of the document. It's omitted when empty.
+	Title string
+
+	// Charset is the character encoding of the document. Defaults to "utf-8".
+	Charset string
+
+	// Viewport is the content of the viewport meta tag. Defaults to
+	// "width=device-width, initial-scale=1".
+	Viewport string
+}
+
+var _ Node = new(DocumentNode)
+
+// DocumentNode is a complete HTML document, starting with the doctype.
+// Children, attributes and classes are added to the <body>, and Head adds
+// children to the <head>.
+type DocumentNode struct {
+	opts DocumentOptions
+	head *RawNode
+	body *RawNode
+	hide bool
+}
+
+// Document creates a complete HTML document, with the doctype, an <html>
+// element, and a <head> containing the charset and viewport meta tags.
+//
+//	Document(DocumentOptions{Lang: "en", Title: "Home"}).
+//		Head(Link().Rel("stylesheet").Href("/main.css")).
+//		Children(H1().Text("Welcome"))
+func Document(opts DocumentOptions) *DocumentNode {
+	return &DocumentNode{
+		opts: opts,
+		head: Fragment().GetNode(),
+		body: Body().GetNode(),
+	}
+}
+
+// Head adds children to the <head> of the document, after the meta tags
+// and title. Entries declared with HeadTitle, HeadMeta and HeadLink anywhere
+// in the document are added after them, followed by the CSS of any
+// Stylesheet used in the document. A title, meta or link here is replaced
+// by an entry with the same key declared in the document, like HeadMeta
+// describes.
+func (d *DocumentNode) Head(children ...Node) *DocumentNode {
+	d.head.Children(children...)
+	return d
+}
+
+func (d *DocumentNode) build() *RawNode {
+	charset := d.opts.Charset
+	if charset == "" {
+		charset = "utf-8"
+	}
+
+	viewport := d.opts.Viewport
+	if viewport == "" {
+		viewport = "width=device-width, initial-scale=1"
+	}
+
+	// Head entries can be declared anywhere in the document, and replace
+	// the default meta tags and title
+	h := newHeadCollector()
+	h.addHead(d.head.children)
+	h.collect([]Node{d.body})
+
+	title := Title().If(d.opts.Title != "").Text(d.opts.Title)
+	if h.title != nil {
+		title = h.title
+	}
+
+	root := Fragment().Children(
+		Doctype(),
+		Html().
+			AttrIf(d.opts.Lang != "", "lang", d.opts.Lang).
+			AttrIf(d.opts.Dir != "", "dir", d.opts.Dir).
+			Children(
+				Head().Children(
+					h.replace(Meta().Attr("charset", charset).GetNode()),
+					h.replace(Meta().Name("viewport").Attr("content", viewport).GetNode()),
+					title,
+					Fragment().Children(h.nodes()...),
+				),
+				d.body,
+			),
+	).GetNode()
+	root.hide = d.hide
+
+	return root
+}
+
+func (d *DocumentNode) ID(id string) Node {
+	d.body.ID(id)
+	return d
+}
+
+func (d *DocumentNode) Children(child ...Node) Node {
+	d.body.Children(child...)
+	return d
+}
+
+func (d *DocumentNode) Range(n int, child func(i int) Node) Node {
+	d.body.Range(n, child)
+	return d
+}
+
+func (d *DocumentNode) Text(text ...string) Node {
+	d.body.Text(text...)
+	return d
+}
+
+func (d *DocumentNode) Textf(format string, a ...interface{}) Node {
+	d.body.Textf(format, a...)
+	return d
+}
+
+func (d *DocumentNode) HTMLUnsafe(html string) Node {
+	d.body.HTMLUnsafe(html)
+	return d
+}
+
+func (d *DocumentNode) Attr(name, value string) Node {
+	d.body.Attr(name, value)
+	return d
+}
+
+func (d *DocumentNode) AttrIf(cond bool, name, value string) Node {
+	d.body.AttrIf(cond, name, value)
+	return d
+}
+
+func (d *DocumentNode) AttrBool(name string) Node {
+	d.body.AttrBool(name)
+	return d
+}
+
+func (d *DocumentNode) Class(cls ...string) Node {
+	d.body.Class(cls...)
+	return d
+}
+
+func (d *DocumentNode) ClassIf(condition bool, cls string) Node {
+	d.body.ClassIf(condition, cls)
+	return d
+}
+
+func (d *DocumentNode) Style(value string) Node {
+	d.body.Style(value)
+	return d
+}
+
+func (d *DocumentNode) StyleProperty(name, value string) Node {
+	d.body.StyleProperty(name, value)
+	return d
+}
+
+func (d *DocumentNode) Styles(s *Styles) Node {
+	d.body.Styles(s)
+	return d
+}
+
+func (d *DocumentNode) Href(value string) Node {
+	d.body.Href(value)
+	return d
+}
+
+func (d *DocumentNode) Name(value string) Node {
+	d.body.Name(value)
+	return d
+}
+
+func (d *DocumentNode) Action(value string) Node {
+	d.body.Action(value)
+	return d
+}
+
+func (d *DocumentNode) Method(value string) Node {
+	d.body.Method(value)
+	return d
+}
+
+func (d *DocumentNode) Rel(value string) Node {
+	d.body.Rel(value)
+	return d
+}
+
+func (d *DocumentNode) Src(value string) Node {
+	d.body.Src(value)
+	return d
+}
+
+func (d *DocumentNode) Target(value string) Node {
+	d.body.Target(value)
+	return d
+}
+
+func (d *DocumentNode) Value(value string) Node {
+	d.body.Value(value)
+	return d
+}
+
+func (d *DocumentNode) Alt(value string) Node {
+	d.body.Alt(value)
+	return d
+}
+
+func (d *DocumentNode) Type(value string) Node {
+	d.body.Type(value)
+	return d
+}
+
+func (d *DocumentNode) Title(value string) Node {
+	d.body.Title(value)
+	return d
+}
+
+func (d *DocumentNode) HxGet(url string) Node {
+	d.body.HxGet(url)
+	return d
+}
+
+func (d *DocumentNode) HxPost(url string) Node {
+	d.body.HxPost(url)
+	return d
+}
+
+func (d *DocumentNode) HxTarget(selector string) Node {
+	d.body.HxTarget(selector)
+	return d
+}
+
+func (d *DocumentNode) HxSwap(swap Swap) Node {
+	d.body.HxSwap(swap)
+	return d
+}
+
+func (d *DocumentNode) HxTrigger(trigger string) Node {
+	d.body.HxTrigger(trigger)
+	return d
+}
+
+func (d *DocumentNode) HxSwapOOB(swap Swap) Node {
+	d.body.HxSwapOOB(swap)
+	return d
+}
+
+func (d *DocumentNode) Data(key, value string) Node {
+	d.body.Data(key, value)
+	return d
+}
+
+func (d *DocumentNode) Aria(key ARIA, value string) Node {
+	d.body.Aria(key, value)
+	return d
+}
+
+func (d *DocumentNode) Role(role Role) Node {
+	d.body.Role(role)
+	return d
+}
+
+func (d *DocumentNode) On(event Event, script string) Node {
+	d.body.On(event, script)
+	return d
+}
+
+func (d *DocumentNode) If(b bool) Node {
+	d.hide = !b
+	return d
+}
+
+func (d *DocumentNode) Extend(base Node) Node {
+	d.body.Extend(base)
+	return d
+}
+
+func (d *DocumentNode) ToHTML() string {
+	return d.build().ToHTML()
+}
+
+func (d *DocumentNode) ToText() string {
+	return d.build().ToText()
+}
+
+func (d *DocumentNode) ToTextWith(opts TextOptions) string {
+	return d.build().ToTextWith(opts)
+}
+
+func (d *DocumentNode) ToMarkdown() string {
+	return d.build().ToMarkdown()
+}
+
+func (d *DocumentNode) ToANSI(opts ANSIOptions) string {
+	return d.build().ToANSI(opts)
+}
+
+func (d *DocumentNode) ToHTMLPretty() string {
+	return d.build().ToHTMLPretty()
+}
+
+func (d *DocumentNode) ToHTMLPrettyWith(opts PrettyOptions) string {
+	return d.build().ToHTMLPrettyWith(opts)
+}
+
+func (d *DocumentNode) ToHTMLMinified(opts MinifyOptions) string {
+	return d.build().ToHTMLMinified(opts)
+}
+
+func (d *DocumentNode) Write(w io.Writer) (int, error) {
+	return d.build().Write(w)
+}
+
+func (d *DocumentNode) WritePretty(w io.Writer) (int, error) {
+	return d.build().WritePretty(w)
+}
+
+func (d *DocumentNode) MustWrite(w io.Writer) {
+	d.build().MustWrite(w)
+}
+
+func (d *DocumentNode) MustWritePretty(w io.Writer) {
+	d.build().MustWritePretty(w)
+}
+
+func (d *DocumentNode) GetNode() *RawNode {
+	return d.build()
+}
@@ -0,0 +1,62 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestDocument(t *testing.T) {
+	t.Run("renders a full document", func(t *testing.T) {
+		root := Document(DocumentOptions{Lang: "en", Dir: "ltr", Title: "Home"}).
+			Head(Link().Rel("stylesheet").Href("/main.css")).
+			Class("home").
+			Children(H1().Text("Welcome"))
+
+		assert.Equal(t, strings.Join([]string{
+			"<!DOCTYPE html>",
+			`<html lang="en" dir="ltr">`,
+			"  <head>",
+			`    <meta charset="utf-8"/>`,
+			`    <meta name="viewport" content="width=device-width, initial-scale=1"/>`,
+			"    <title>Home</title>",
+			`    <link rel="stylesheet" href="/main.css"/>`,
+			"  </head>",
+			`  <body class="home">`,
+			"    <h1>Welcome</h1>",
+			"  </body>",
+			"</html>",
+		}, "\n"), root.ToHTMLPretty())
+	})
+
+	t.Run("omits empty options", func(t *testing.T) {
+		root := Document(DocumentOptions{Charset: "iso-8859-1", Viewport: "width=500"})
+		assert.Equal(t, `<!DOCTYPE html><html><head>`+
+			`<meta charset="iso-8859-1"/><meta name="viewport" content="width=500"/>`+
+			`</head><body></body></html>`, root.ToHTML())
+	})
+
+	t.Run("renders only the body as text", func(t *testing.T) {
+		root := Document(DocumentOptions{Title: "Home"}).
+			Head(Script().Text("init()")).
+			Children(H1().Text("Welcome"), Style().Text("h1 { color: red }"), P().Text("Hello"))
+
+		assert.Equal(t, "Welcome\n\nHello", root.ToText())
+		assert.Equal(t, "# Welcome\n\nHello", root.ToMarkdown())
+	})
+
+	t.Run("can be nested in other nodes", func(t *testing.T) {
+		root := Fragment().Children(Document(DocumentOptions{}).Children(Text("Hi")))
+		assert.Contains(t, root.ToHTML(), "<body>Hi</body>")
+	})
+}
+
+func TestDoctype(t *testing.T) {
+	root := Fragment().Children(Doctype(), Html().Children(Body()))
+	assert.Equal(t, "<!DOCTYPE html><html><body></body></html>", root.ToHTML())
+	assert.Equal(t, "<!DOCTYPE html>\n<html>\n  <body></body>\n</html>", root.ToHTMLPretty())
+	assert.Equal(t, "<!DOCTYPE html><html><body></html>", root.ToHTMLMinified(MinifyOptions{}))
+	assert.Equal(t, "", root.ToText())
+}
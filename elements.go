@@ -204,7 +204,7 @@ func Input() Node {
 }
 
 func Textarea() Node {
-	return newTagNode("textarea")
+	return newPreserveWhitespaceTagNode("textarea")
 }
 
 func Button() Node {
@@ -496,6 +496,7 @@ func Textf(text string, a ...interface{}) Node {
 func UnsafeText(text ...string) Node {
 	el := newEl()
 	el.text = strings.Join(text, " ")
+	el.unsafe = true
 	el.nodeType = textNode
 	return el
 }
@@ -509,6 +510,15 @@ func Fragment() Node {
 	return el
 }
 
+// Doctype is a special element that renders the HTML5 doctype,
+// <!DOCTYPE html>. It's ignored when rendering text.
+func Doctype() Node {
+	el := newEl()
+	el.nodeType = doctypeNode
+	el.indentIncrement = 0
+	return el
+}
+
 func Comment(text string) Node {
 	el := newEl()
 	el.Children(Text(text))
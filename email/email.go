@@ -0,0 +1,290 @@
+// Package email builds MIME email messages from hagl nodes. Each message
+// has both an HTML and a plain text version of the body, along with any
+// images it references by Content-ID.
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gschier/hagl"
+)
+
+// Message is an email with an HTML body and its plain text alternative
+type Message struct {
+	From    mail.Address
+	To      []mail.Address
+	Cc      []mail.Address
+	ReplyTo []mail.Address
+	Subject string
+
+	// Date is the date the message was written. Defaults to the current time.
+	Date time.Time
+
+	// MessageID is the unique ID of the message, like
+	// "<123@mail.example.com>". It's omitted when empty, leaving it for the
+	// mail server to add.
+	MessageID string
+
+	// Headers are any other headers to add to the message
+	Headers map[string]string
+
+	// Body is rendered as HTML, and as text for the plain text alternative
+	Body hagl.Node
+
+	// TextOptions configures the plain text alternative. Defaults to
+	// hagl.DefaultTextOptions.
+	TextOptions *hagl.TextOptions
+
+	// Images are attached to the message, to be referenced from the body
+	// with Img().Src("cid:" + image.ContentID)
+	Images []Image
+}
+
+// Image is an image attached to a message for display in its body
+type Image struct {
+	// ContentID identifies the image, without the angle brackets
+	ContentID string
+
+	// ContentType is the media type of the image, like "image/png"
+	ContentType string
+
+	// Filename is shown by clients that list the image as an attachment
+	Filename string
+
+	Data []byte
+}
+
+// WriteTo writes the message in the format of RFC 5322, with the body as a
+// multipart/alternative of the plain text and HTML. When there are images,
+// it's wrapped in a multipart/related containing them.
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := m.write(cw)
+	return cw.n, err
+}
+
+// Bytes returns the message as written by WriteTo
+func (m *Message) Bytes() ([]byte, error) {
+	var b bytes.Buffer
+	if _, err := m.WriteTo(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (m *Message) write(w io.Writer) error {
+	if m.From.Address == "" {
+		return errors.New("email: message has no From address")
+	}
+
+	if m.Body == nil {
+		return errors.New("email: message has no Body")
+	}
+
+	for _, img := range m.Images {
+		if img.ContentID == "" || img.ContentType == "" {
+			return errors.New("email: images need a ContentID and ContentType")
+		}
+	}
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	h := &headerWriter{w: w}
+	h.write("From", m.From.String())
+	h.write("To", addressList(m.To))
+	h.write("Cc", addressList(m.Cc))
+	h.write("Reply-To", addressList(m.ReplyTo))
+	h.write("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	h.write("Date", date.Format(time.RFC1123Z))
+	h.write("Message-ID", m.MessageID)
+	h.write("MIME-Version", "1.0")
+
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h.write(textproto.CanonicalMIMEHeaderKey(k), mime.QEncoding.Encode("utf-8", m.Headers[k]))
+	}
+
+	mw := multipart.NewWriter(w)
+	if len(m.Images) == 0 {
+		h.write("Content-Type", multipartType("alternative", mw.Boundary()))
+		if err := h.end(); err != nil {
+			return err
+		}
+		return m.writeAlternative(mw)
+	}
+
+	// RFC 2387 requires the type of the root part, which is the alternative
+	h.write("Content-Type", multipartType("related", mw.Boundary(), "type", "multipart/alternative"))
+	if err := h.end(); err != nil {
+		return err
+	}
+
+	// The alternative is nested inside the related part, followed by the
+	// images that it references
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {multipartType("alternative", boundary)},
+	})
+	if err != nil {
+		return err
+	}
+
+	alt := multipart.NewWriter(part)
+	if err := alt.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	if err := m.writeAlternative(alt); err != nil {
+		return err
+	}
+
+	for _, img := range m.Images {
+		if err := writeImage(mw, img); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+func (m *Message) writeAlternative(mw *multipart.Writer) error {
+	opts := hagl.DefaultTextOptions()
+	if m.TextOptions != nil {
+		opts = *m.TextOptions
+	}
+
+	if err := writeQuotedPrintable(mw, "text/plain", m.Body.ToTextWith(opts)); err != nil {
+		return err
+	}
+
+	if err := writeQuotedPrintable(mw, "text/html", m.Body.ToHTML()); err != nil {
+		return err
+	}
+
+	return mw.Close()
+}
+
+func writeQuotedPrintable(mw *multipart.Writer, contentType, body string) error {
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(qp, body); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+func writeImage(mw *multipart.Writer, img Image) error {
+	disposition := "inline"
+	if img.Filename != "" {
+		disposition = mime.FormatMediaType("inline", map[string]string{"filename": img.Filename})
+	}
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {img.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {disposition},
+		"Content-Id":                {"<" + img.ContentID + ">"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Base64 lines can't be longer than 76 characters
+	encoded := base64.StdEncoding.EncodeToString(img.Data)
+	for len(encoded) > 0 {
+		n := 76
+		if len(encoded) < n {
+			n = len(encoded)
+		}
+
+		if _, err := io.WriteString(part, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
+
+// multipartType formats the Content-Type of a multipart with the boundary
+// and extra parameters, given as pairs of names and values
+func multipartType(subtype, boundary string, params ...string) string {
+	p := map[string]string{"boundary": boundary}
+	for i := 0; i+1 < len(params); i += 2 {
+		p[params[i]] = params[i+1]
+	}
+	return mime.FormatMediaType("multipart/"+subtype, p)
+}
+
+func addressList(addresses []mail.Address) string {
+	items := make([]string, len(addresses))
+	for i, a := range addresses {
+		items[i] = a.String()
+	}
+	return strings.Join(items, ", ")
+}
+
+// headerWriter writes header fields, skipping those with empty values and
+// keeping the first error
+type headerWriter struct {
+	w   io.Writer
+	err error
+}
+
+// headerBreaks are removed from values so they can't add header fields
+var headerBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+func (h *headerWriter) write(name, value string) {
+	if h.err != nil || value == "" {
+		return
+	}
+
+	_, h.err = io.WriteString(h.w, headerBreaks.Replace(name+": "+value)+"\r\n")
+}
+
+// end writes the blank line that ends the header section
+func (h *headerWriter) end() error {
+	if h.err != nil {
+		return h.err
+	}
+
+	_, h.err = io.WriteString(h.w, "\r\n")
+	return h.err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
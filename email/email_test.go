@@ -0,0 +1,147 @@
+package email_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+	"github.com/gschier/hagl/email"
+)
+
+func TestMessage_WriteTo(t *testing.T) {
+	t.Run("writes html and text alternatives", func(t *testing.T) {
+		m := &email.Message{
+			From:      mail.Address{Name: "Yaak", Address: "hi@yaak.app"},
+			To:        []mail.Address{{Address: "a@example.com"}, {Name: "Zoë", Address: "z@example.com"}},
+			Subject:   "Welcome, Zoë",
+			Date:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			MessageID: "<1@yaak.app>",
+			Headers:   map[string]string{"x-campaign": "welcome\r\nBcc: evil@example.com"},
+			Body: Div().Children(
+				H1().Text("Welcome"),
+				P().Text("Thanks for signing up, it's great to have you here = really!"),
+			),
+		}
+
+		var b bytes.Buffer
+		n, err := m.WriteTo(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(b.Len()), n)
+
+		msg, err := mail.ReadMessage(&b)
+		assert.NoError(t, err)
+		assert.Equal(t, `"Yaak" <hi@yaak.app>`, msg.Header.Get("From"))
+		assert.Equal(t, `<a@example.com>, =?utf-8?q?Zo=C3=AB?= <z@example.com>`, msg.Header.Get("To"))
+		assert.Equal(t, "=?utf-8?q?Welcome,_Zo=C3=AB?=", msg.Header.Get("Subject"))
+		assert.Equal(t, "Tue, 02 Jan 2024 03:04:05 +0000", msg.Header.Get("Date"))
+		assert.Equal(t, "<1@yaak.app>", msg.Header.Get("Message-ID"))
+		assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+		assert.Equal(t, "=?utf-8?q?welcome=0D=0ABcc:_evil@example.com?=", msg.Header.Get("X-Campaign"))
+		assert.Equal(t, "", msg.Header.Get("Bcc"))
+
+		parts := readParts(t, msg.Header.Get("Content-Type"), msg.Body, "multipart/alternative")
+		assert.Len(t, parts, 2)
+
+		assert.Equal(t, "text/plain; charset=utf-8", parts[0].Header.Get("Content-Type"))
+		assert.Equal(t, "quoted-printable", parts[0].Header.Get("Content-Transfer-Encoding"))
+		assert.Equal(t, "Welcome\r\n\r\nThanks for signing up, it's great to have you here = really!", decodeQP(t, parts[0].body))
+		assert.Contains(t, parts[0].body, "=3D really!")
+
+		assert.Equal(t, "text/html; charset=utf-8", parts[1].Header.Get("Content-Type"))
+		assert.Equal(t, m.Body.ToHTML(), decodeQP(t, parts[1].body))
+	})
+
+	t.Run("wraps images in a related part", func(t *testing.T) {
+		data := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 40)
+		m := &email.Message{
+			From:    mail.Address{Address: "hi@yaak.app"},
+			To:      []mail.Address{{Address: "a@example.com"}},
+			Subject: "Logo",
+			Body:    Img().Src("cid:logo").Alt("Yaak"),
+			Images: []email.Image{
+				{ContentID: "logo", ContentType: "image/png", Filename: "logo.png", Data: data},
+			},
+		}
+
+		raw, err := m.Bytes()
+		assert.NoError(t, err)
+
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, msg.Header.Get("Date"))
+
+		_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", params["type"])
+
+		related := readParts(t, msg.Header.Get("Content-Type"), msg.Body, "multipart/related")
+		assert.Len(t, related, 2)
+
+		alternative := readParts(t, related[0].Header.Get("Content-Type"), strings.NewReader(related[0].body), "multipart/alternative")
+		assert.Len(t, alternative, 2)
+		assert.Equal(t, "[Yaak]", decodeQP(t, alternative[0].body))
+		assert.Equal(t, `<img src="cid:logo" alt="Yaak"/>`, decodeQP(t, alternative[1].body))
+
+		img := related[1]
+		assert.Equal(t, "image/png", img.Header.Get("Content-Type"))
+		assert.Equal(t, "<logo>", img.Header.Get("Content-Id"))
+		assert.Equal(t, `inline; filename=logo.png`, img.Header.Get("Content-Disposition"))
+		for _, line := range strings.Split(strings.TrimSpace(img.body), "\r\n") {
+			assert.LessOrEqual(t, len(line), 76)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(img.body, "\r\n", ""))
+		assert.NoError(t, err)
+		assert.Equal(t, data, decoded)
+	})
+
+	t.Run("requires a sender and body", func(t *testing.T) {
+		_, err := (&email.Message{Body: P()}).Bytes()
+		assert.EqualError(t, err, "email: message has no From address")
+
+		_, err = (&email.Message{From: mail.Address{Address: "hi@yaak.app"}}).Bytes()
+		assert.EqualError(t, err, "email: message has no Body")
+	})
+}
+
+type part struct {
+	*multipart.Part
+	body string
+}
+
+// readParts reads the raw parts of a multipart body, without decoding them
+func readParts(t *testing.T, contentType string, body io.Reader, expected string) []part {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, mediaType)
+
+	var parts []part
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := r.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		assert.NoError(t, err)
+
+		b, err := io.ReadAll(p)
+		assert.NoError(t, err)
+		parts = append(parts, part{Part: p, body: string(b)})
+	}
+}
+
+func decodeQP(t *testing.T, s string) string {
+	b, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(s)))
+	assert.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,59 @@
+// Package hagltest has helpers for testing hagl nodes, which compare the
+// structure of rendered HTML rather than its exact text.
+package hagltest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/gschier/hagl"
+)
+
+// AssertHTMLEqual fails the test if the node doesn't render the same HTML
+// as expected, and shows the difference between the trees. Attribute order,
+// class order and whitespace that isn't displayed are ignored, so these are
+// equal:
+//
+//	<ul class="b a"><li id="1" hidden>One</li></ul>
+//
+//	<ul class="a b">
+//	  <li hidden id="1">One</li>
+//	</ul>
+func AssertHTMLEqual(t testing.TB, expected string, node hagl.Node) bool {
+	t.Helper()
+
+	if diff := DiffHTML(expected, node.ToHTML()); diff != "" {
+		t.Errorf("HTML not equal:\n%s", diff)
+		return false
+	}
+	return true
+}
+
+// DiffHTML compares two HTML strings like AssertHTMLEqual, returning a
+// unified diff of their trees, or "" if they're equal
+func DiffHTML(expected, actual string) string {
+	e, a := parseHTML(expected), parseHTML(actual)
+	normalize(e, false)
+	normalize(a, false)
+
+	expectedTree, actualTree := format(e), format(a)
+	if expectedTree == actualTree {
+		return ""
+	}
+
+	return unifiedDiff("expected", "actual", expectedTree, actualTree)
+}
+
+// unifiedDiff returns a unified diff of two texts with the given names
+func unifiedDiff(fromName, toName, from, to string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimSuffix(from, "\n")),
+		B:        difflib.SplitLines(strings.TrimSuffix(to, "\n")),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	return diff
+}
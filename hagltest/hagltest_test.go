@@ -0,0 +1,102 @@
+package hagltest_test
+
+import (
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+	"github.com/gschier/hagl/hagltest"
+)
+
+// recorder records the errors of a test instead of failing it
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Logf(format string, args ...interface{}) {}
+
+func TestAssertHTMLEqual(t *testing.T) {
+	t.Run("ignores attribute and class order", func(t *testing.T) {
+		root := Button().Class("btn", "btn--primary").Type("button").ID("save").Text("Save")
+		hagltest.AssertHTMLEqual(t, `<button id="save" type=button class="btn--primary  btn">Save</button>`, root)
+	})
+
+	t.Run("ignores whitespace that isn't displayed", func(t *testing.T) {
+		root := Div().Children(
+			H1().Text("Hello"),
+			P().Children(Text("Hi"), Strong().Text("there"), Text("!")),
+			Pre().Text("  a\n  b"),
+		)
+		hagltest.AssertHTMLEqual(t, `
+			<div>
+				<h1> Hello </h1>
+				<p>Hi<strong>there</strong>!</p>
+				<pre>  a
+  b</pre>
+			</div>
+		`, root)
+		hagltest.AssertHTMLEqual(t, root.ToHTMLPretty(), root)
+	})
+
+	t.Run("compares text and entities", func(t *testing.T) {
+		root := P().Text(`Tom & "Jerry"`).Attr("title", "<3")
+		hagltest.AssertHTMLEqual(t, `<p title="&lt;3">Tom &amp; &#34;Jerry&#34;</p>`, root)
+		hagltest.AssertHTMLEqual(t, `<p title='<3'>Tom &amp; "Jerry"</p>`, root)
+	})
+
+	t.Run("reports differences as a tree diff", func(t *testing.T) {
+		r := &recorder{}
+		ok := hagltest.AssertHTMLEqual(r, `<ul class="list"><li>One</li><li>Two</li></ul>`,
+			Ul().Class("list").Children(Li().Text("One"), Li().Text("Three")),
+		)
+		assert.False(t, ok)
+		assert.Equal(t, []string{
+			"HTML not equal:\n" +
+				"--- expected\n" +
+				"+++ actual\n" +
+				"@@ -2,4 +2,4 @@\n" +
+				"   <li>\n" +
+				"     \"One\"\n" +
+				"   <li>\n" +
+				"-    \"Two\"\n" +
+				"+    \"Three\"\n",
+		}, r.errors)
+	})
+
+	t.Run("treats significant whitespace as different", func(t *testing.T) {
+		assert.NotEmpty(t, hagltest.DiffHTML(`<p>a <b>b</b></p>`, `<p>a<b>b</b></p>`))
+		assert.NotEmpty(t, hagltest.DiffHTML(`<pre>a </pre>`, `<pre>a</pre>`))
+		assert.NotEmpty(t, hagltest.DiffHTML(`<p>a <!--c--> b</p>`, `<p>a<!--c-->b</p>`))
+		assert.Empty(t, hagltest.DiffHTML(`<div> <!--c--> <p>a</p> </div>`, `<div><!--c--><p>a</p></div>`))
+	})
+
+	t.Run("treats whitespace around custom and media elements as significant", func(t *testing.T) {
+		assert.NotEmpty(t, hagltest.DiffHTML(`<p>Play <video></video> now</p>`, `<p>Play<video></video>now</p>`))
+		assert.NotEmpty(t, hagltest.DiffHTML(`<p>a <x-b>b</x-b> c</p>`, `<p>a<x-b>b</x-b>c</p>`))
+		assert.NotEmpty(t, hagltest.DiffHTML(`<p><x-b> b </x-b></p>`, `<p><x-b>b</x-b></p>`))
+		for _, tag := range []string{"audio", "svg", "iframe", "canvas", "object", "picture"} {
+			assert.NotEmpty(t, hagltest.DiffHTML(`<p>a <`+tag+`></`+tag+`> b</p>`, `<p>a<`+tag+`></`+tag+`>b</p>`), tag)
+		}
+		assert.Empty(t, hagltest.DiffHTML(`<table> <tr> <td>a</td> </tr> </table>`, `<table><tr><td>a</td></tr></table>`))
+		assert.Empty(t, hagltest.DiffHTML(`<div> <p>a</p> </div>`, `<div><p>a</p></div>`))
+	})
+
+	t.Run("parses void and raw text elements", func(t *testing.T) {
+		root := Fragment().Children(
+			Doctype(),
+			Input().Name("q"),
+			Br(),
+			Script().HTMLUnsafe(`if (a < b) { go("</p>") }`),
+		)
+		hagltest.AssertHTMLEqual(t, `<!doctype html><input name="q"/><br><script>if (a < b) { go("</p>") }</script>`, root)
+	})
+}
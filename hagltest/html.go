@@ -0,0 +1,391 @@
+package hagltest
+
+import (
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type nodeKind int
+
+const (
+	elementNode nodeKind = iota
+	textNode
+	commentNode
+	doctypeNode
+)
+
+// node is an element, text, comment or doctype parsed from HTML
+type node struct {
+	kind     nodeKind
+	tag      string
+	attrs    []attr
+	text     string
+	children []*node
+}
+
+type attr struct {
+	name  string
+	value string
+}
+
+var voidEls = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextEls contain text that isn't parsed as HTML
+var rawTextEls = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true,
+}
+
+// preformattedEls keep the whitespace of their text
+var preformattedEls = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true,
+}
+
+// notInlineEls are the elements that aren't laid out inline, the same as in
+// hagl's pretty printer. Whitespace around every other element, including
+// custom and unknown ones, is significant.
+var notInlineEls = map[string]bool{
+	// Blocks
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"div": true, "dl": true, "fieldset": true, "figure": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "header": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"table": true, "ul": true,
+
+	// Document structure and metadata
+	"html": true, "head": true, "body": true, "base": true, "link": true,
+	"meta": true, "style": true, "title": true, "script": true,
+	"noscript": true, "template": true,
+
+	// Parts of lists, tables, forms and media elements
+	"dd": true, "dt": true, "caption": true, "col": true, "colgroup": true,
+	"tbody": true, "thead": true, "tfoot": true, "tr": true, "td": true,
+	"th": true, "legend": true, "datalist": true, "optgroup": true,
+	"option": true, "area": true, "param": true, "source": true, "track": true,
+
+	// Sectioning and interactive blocks
+	"details": true, "dialog": true, "figcaption": true, "hgroup": true,
+	"menu": true, "search": true, "summary": true,
+}
+
+// isInline returns whether the element is laid out inline. The root of a
+// parsed tree has no tag and isn't.
+func isInline(n *node) bool {
+	return n.kind == elementNode && n.tag != "" && !notInlineEls[n.tag]
+}
+
+// parseHTML parses a fragment of HTML into a tree, under a root node. It's
+// lenient, like browsers are, but doesn't add the elements that browsers
+// imply, like <tbody>.
+func parseHTML(s string) *node {
+	root := &node{kind: elementNode}
+	stack := []*node{root}
+	top := func() *node { return stack[len(stack)-1] }
+
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			end := strings.Index(s[4:], "-->")
+			if end < 0 {
+				end = len(s) - 4
+			}
+			top().children = append(top().children, &node{kind: commentNode, text: s[4 : 4+end]})
+			s = s[minInt(4+end+3, len(s)):]
+
+		case strings.HasPrefix(s, "<!"):
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				end = len(s) - 1
+			}
+			top().children = append(top().children, &node{kind: doctypeNode, text: strings.ToLower(strings.Join(strings.Fields(s[2:end]), " "))})
+			s = s[end+1:]
+
+		case strings.HasPrefix(s, "</") && len(s) > 2 && isLetter(s[2]):
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				end = len(s) - 1
+			}
+			tag := strings.ToLower(strings.TrimSpace(s[2:end]))
+			s = s[end+1:]
+
+			// Close the element, along with any left open inside it
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+
+		case s[0] == '<' && len(s) > 1 && isLetter(s[1]):
+			var n *node
+			var selfClosing bool
+			n, selfClosing, s = parseStartTag(s)
+			top().children = append(top().children, n)
+
+			if rawTextEls[n.tag] {
+				end := indexFold(s, "</"+n.tag)
+				if end < 0 {
+					end = len(s)
+				}
+				text := s[:end]
+				if n.tag == "textarea" || n.tag == "title" {
+					text = html.UnescapeString(text)
+				}
+				if text != "" {
+					n.children = append(n.children, &node{kind: textNode, text: text})
+				}
+				s = s[end:]
+				if gt := strings.IndexByte(s, '>'); gt >= 0 {
+					s = s[gt+1:]
+				}
+			} else if !selfClosing && !voidEls[n.tag] {
+				stack = append(stack, n)
+			}
+
+		default:
+			end := strings.IndexByte(s[1:], '<') + 1
+			if end == 0 {
+				end = len(s)
+			}
+			top().children = append(top().children, &node{kind: textNode, text: html.UnescapeString(s[:end])})
+			s = s[end:]
+		}
+	}
+
+	return root
+}
+
+// parseStartTag parses the start tag at the beginning of s, returning the
+// element, whether it closed itself, and the rest of s
+func parseStartTag(s string) (*node, bool, string) {
+	i := 1
+	for i < len(s) && !isSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	n := &node{kind: elementNode, tag: strings.ToLower(s[1:i])}
+
+	for {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+
+		if i >= len(s) {
+			return n, false, ""
+		}
+
+		if s[i] == '>' {
+			return n, false, s[i+1:]
+		}
+
+		if strings.HasPrefix(s[i:], "/>") {
+			return n, true, s[i+2:]
+		}
+
+		if s[i] == '/' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '>' && s[i] != '=' && !strings.HasPrefix(s[i:], "/>") {
+			i++
+		}
+		a := attr{name: strings.ToLower(s[start:i])}
+
+		j := i
+		for j < len(s) && isSpace(s[j]) {
+			j++
+		}
+		if j < len(s) && s[j] == '=' {
+			i = j + 1
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				end := strings.IndexByte(s[i+1:], s[i])
+				if end < 0 {
+					end = len(s) - i - 1
+				}
+				a.value = s[i+1 : i+1+end]
+				i = minInt(i+1+end+1, len(s))
+			} else {
+				start := i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				a.value = s[start:i]
+			}
+			a.value = html.UnescapeString(a.value)
+		}
+
+		n.attrs = append(n.attrs, a)
+	}
+}
+
+// normalize rewrites the tree so that HTML that displays the same way is
+// the same: attributes and classes are sorted, whitespace is collapsed, and
+// whitespace that isn't displayed is removed
+func normalize(n *node, preformatted bool) {
+	if n.kind != elementNode {
+		return
+	}
+
+	for i, a := range n.attrs {
+		switch a.name {
+		case "class":
+			classes := strings.Fields(a.value)
+			sort.Strings(classes)
+			n.attrs[i].value = strings.Join(classes, " ")
+		case "style":
+			var decls []string
+			for _, d := range strings.Split(a.value, ";") {
+				if name, value, ok := strings.Cut(d, ":"); ok {
+					decls = append(decls, strings.TrimSpace(name)+": "+strings.Join(strings.Fields(value), " "))
+				}
+			}
+			n.attrs[i].value = strings.Join(decls, "; ")
+		}
+	}
+	sort.SliceStable(n.attrs, func(i, j int) bool {
+		return n.attrs[i].name < n.attrs[j].name
+	})
+
+	preformatted = preformatted || preformattedEls[n.tag]
+
+	// Join adjacent text, which is split by the parser at stray <
+	var children []*node
+	for _, c := range n.children {
+		if last := len(children) - 1; c.kind == textNode && last >= 0 && children[last].kind == textNode {
+			children[last] = &node{kind: textNode, text: children[last].text + c.text}
+			continue
+		}
+		children = append(children, c)
+	}
+
+	if !preformatted {
+		block := !isInline(n)
+		for i, c := range children {
+			if c.kind != textNode {
+				continue
+			}
+
+			c.text = collapseSpace(c.text)
+			if prev := sibling(children, i, -1); (prev == nil && block) || (prev != nil && isBlockNode(prev)) {
+				c.text = strings.TrimLeft(c.text, " ")
+			}
+			if next := sibling(children, i, 1); (next == nil && block) || (next != nil && isBlockNode(next)) {
+				c.text = strings.TrimRight(c.text, " ")
+			}
+		}
+
+		kept := children[:0]
+		for _, c := range children {
+			if c.kind != textNode || c.text != "" {
+				kept = append(kept, c)
+			}
+		}
+		children = kept
+	}
+
+	for _, c := range children {
+		normalize(c, preformatted)
+	}
+	n.children = children
+}
+
+// sibling returns the nearest sibling of children[i] in the direction of
+// step, skipping comments because they aren't displayed, or nil if there
+// isn't one
+func sibling(children []*node, i, step int) *node {
+	for i += step; i >= 0 && i < len(children); i += step {
+		if children[i].kind != commentNode {
+			return children[i]
+		}
+	}
+	return nil
+}
+
+func isBlockNode(n *node) bool {
+	return n.kind == elementNode && !isInline(n) || n.kind == doctypeNode
+}
+
+// format writes the tree with one node per line, indented by depth, for
+// showing differences between trees
+func format(n *node) string {
+	var b strings.Builder
+	for _, c := range n.children {
+		formatNode(&b, c, 0)
+	}
+	return b.String()
+}
+
+func formatNode(b *strings.Builder, n *node, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+
+	switch n.kind {
+	case textNode:
+		b.WriteString(strconv.Quote(n.text))
+	case commentNode:
+		b.WriteString("<!--" + n.text + "-->")
+	case doctypeNode:
+		b.WriteString("<!" + n.text + ">")
+	case elementNode:
+		b.WriteString("<" + n.tag)
+		for _, a := range n.attrs {
+			b.WriteString(" " + a.name + "=" + strconv.Quote(a.value))
+		}
+		b.WriteString(">")
+	}
+	b.WriteString("\n")
+
+	for _, c := range n.children {
+		formatNode(b, c, depth+1)
+	}
+}
+
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		if isSpace(s[i]) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// indexFold is strings.Index, ignoring ASCII case
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
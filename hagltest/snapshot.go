@@ -0,0 +1,103 @@
+package hagltest
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gschier/hagl"
+)
+
+var update = flag.Bool("hagltest.update", false, "update the golden files of hagltest.Snapshot")
+
+// updating returns whether golden files should be written, from the
+// -hagltest.update flag, or from an -update flag defined by the test
+// package itself
+func updating() bool {
+	if *update {
+		return true
+	}
+
+	if f := flag.Lookup("update"); f != nil {
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value, _ := getter.Get().(bool)
+			return value
+		}
+	}
+	return false
+}
+
+// SnapshotOptions configures SnapshotWith
+type SnapshotOptions struct {
+	// Pretty configures the HTML in the snapshot
+	Pretty hagl.PrettyOptions
+
+	// Text adds a snapshot of the node as text, from ToText, in a second
+	// file ending with .text.golden
+	Text bool
+}
+
+// DefaultSnapshotOptions returns the options used by Snapshot
+func DefaultSnapshotOptions() SnapshotOptions {
+	return SnapshotOptions{Pretty: hagl.DefaultPrettyOptions()}
+}
+
+// Snapshot compares the pretty HTML of the node with the golden file
+// testdata/<name>.golden, failing the test with a diff if they differ.
+// The file is written when the test is run with the -hagltest.update flag,
+// or with an -update flag if the test package defines one, and a missing
+// file fails the test otherwise:
+//
+//	go test ./components -run TestCard -hagltest.update
+func Snapshot(t testing.TB, name string, node hagl.Node) bool {
+	t.Helper()
+	return SnapshotWith(t, name, node, DefaultSnapshotOptions())
+}
+
+// SnapshotWith is the same as Snapshot, but accepts options
+func SnapshotWith(t testing.TB, name string, node hagl.Node, opts SnapshotOptions) bool {
+	t.Helper()
+
+	ok := checkGolden(t, name+".golden", node.ToHTMLPrettyWith(opts.Pretty)+"\n")
+	if opts.Text {
+		ok = checkGolden(t, name+".text.golden", node.ToText()+"\n") && ok
+	}
+	return ok
+}
+
+func checkGolden(t testing.TB, file, actual string) bool {
+	t.Helper()
+
+	path := filepath.Join("testdata", filepath.FromSlash(file))
+	expected, err := os.ReadFile(path)
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("writing snapshot: %v", err)
+			return false
+		}
+
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Errorf("writing snapshot: %v", err)
+			return false
+		}
+
+		t.Logf("wrote snapshot %s", path)
+		return true
+	} else if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("snapshot %s doesn't exist, run with -hagltest.update to write it", path)
+		return false
+	} else if err != nil {
+		t.Errorf("reading snapshot: %v", err)
+		return false
+	}
+
+	if string(expected) == actual {
+		return true
+	}
+
+	t.Errorf("snapshot %s doesn't match, run with -hagltest.update to update it:\n%s", path, unifiedDiff(path, "actual", string(expected), actual))
+	return false
+}
@@ -0,0 +1,112 @@
+package hagltest_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+	"github.com/gschier/hagl/hagltest"
+)
+
+// Test packages can define their own -update flag, which Snapshot uses too
+var _ = flag.Bool("update", false, "update golden files")
+
+// inTempDir runs the test in an empty directory, so snapshots are written
+// there instead of to testdata
+func inTempDir(t *testing.T) {
+	if flag.Lookup("hagltest.update").Value.String() == "true" || flag.Lookup("update").Value.String() == "true" {
+		t.Skip("snapshots are always written with -hagltest.update")
+	}
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// setFlag sets a flag for the rest of the test
+func setFlag(t *testing.T, name string, value bool) {
+	assert.NoError(t, flag.Set(name, strconv.FormatBool(value)))
+	t.Cleanup(func() { _ = flag.Set(name, "false") })
+}
+
+func TestSnapshot(t *testing.T) {
+	card := func(title string) Node {
+		return Div().Class("card").Children(H2().Text(title), P().Text("Body"))
+	}
+
+	t.Run("fails on missing snapshots", func(t *testing.T) {
+		inTempDir(t)
+
+		r := &recorder{}
+		assert.False(t, hagltest.Snapshot(r, "card", card("Title")))
+		assert.Equal(t, []string{
+			"snapshot " + filepath.Join("testdata", "card.golden") + " doesn't exist, run with -hagltest.update to write it",
+		}, r.errors)
+
+		_, err := os.Stat(filepath.Join("testdata", "card.golden"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("writes snapshots when updating", func(t *testing.T) {
+		inTempDir(t)
+		setFlag(t, "hagltest.update", true)
+
+		r := &recorder{}
+		assert.True(t, hagltest.SnapshotWith(r, "cards/card", card("Title"), hagltest.SnapshotOptions{
+			Pretty: DefaultPrettyOptions(),
+			Text:   true,
+		}))
+		assert.Empty(t, r.errors)
+
+		html, err := os.ReadFile(filepath.Join("testdata", "cards", "card.golden"))
+		assert.NoError(t, err)
+		assert.Equal(t, "<div class=\"card\">\n  <h2>Title</h2>\n  <p>Body</p>\n</div>\n", string(html))
+
+		text, err := os.ReadFile(filepath.Join("testdata", "cards", "card.text.golden"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Title\n\nBody\n", string(text))
+	})
+
+	t.Run("writes snapshots with the package's -update flag", func(t *testing.T) {
+		inTempDir(t)
+		setFlag(t, "update", true)
+
+		r := &recorder{}
+		assert.True(t, hagltest.Snapshot(r, "card", card("Title")))
+		assert.Empty(t, r.errors)
+
+		_, err := os.Stat(filepath.Join("testdata", "card.golden"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("compares with existing snapshots", func(t *testing.T) {
+		inTempDir(t)
+
+		setFlag(t, "hagltest.update", true)
+		r := &recorder{}
+		assert.True(t, hagltest.Snapshot(r, "card", card("Title")))
+
+		setFlag(t, "hagltest.update", false)
+		assert.True(t, hagltest.Snapshot(r, "card", card("Title")))
+		assert.Empty(t, r.errors)
+
+		assert.False(t, hagltest.Snapshot(r, "card", card("Other")))
+		assert.Equal(t, []string{
+			"snapshot " + filepath.Join("testdata", "card.golden") + " doesn't match, run with -hagltest.update to update it:\n" +
+				"--- " + filepath.Join("testdata", "card.golden") + "\n" +
+				"+++ actual\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				" <div class=\"card\">\n" +
+				"-  <h2>Title</h2>\n" +
+				"+  <h2>Other</h2>\n" +
+				"   <p>Body</p>\n" +
+				" </div>\n",
+		}, r.errors)
+	})
+}
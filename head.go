@@ -0,0 +1,189 @@
+package hagl
+
+import (
+	"slices"
+	"strings"
+)
+
+// HeadTitle sets the <title> of the Document it's rendered in, from
+// anywhere in the tree. If there are several, the last one wins. Outside of
+// a Document, it renders nothing.
+func HeadTitle(title string) Node {
+	return newHeadNode(Title().Text(title))
+}
+
+// HeadMeta adds a <meta> to the <head> of the Document it's rendered in,
+// from anywhere in the tree. A meta with the same name, property or
+// http-equiv as an earlier one replaces it, including those given to
+// Document.Head. Outside of a Document, it renders nothing.
+//
+//	HeadMeta(Meta().Attr("property", "og:title").Attr("content", "Shoes"))
+func HeadMeta(meta Node) Node {
+	return newHeadNode(meta)
+}
+
+// HeadLink adds a <link> to the <head> of the Document it's rendered in,
+// from anywhere in the tree. A link with the same rel as an earlier one
+// replaces it, including those given to Document.Head, except for rels
+// like stylesheet that can appear more than once, where the href must match
+// as well. Outside of a Document, it renders nothing.
+//
+//	HeadLink(Link().Rel("canonical").Href("https://example.com/shoes"))
+func HeadLink(link Node) Node {
+	return newHeadNode(link)
+}
+
+func newHeadNode(n Node) *RawNode {
+	el := newEl()
+	el.nodeType = headNode
+	el.indentIncrement = 0
+	el.children = []Node{n}
+	return el
+}
+
+// multipleLinkRels are link types that are allowed more than once in a
+// document
+var multipleLinkRels = []string{
+	"alternate", "dns-prefetch", "icon", "modulepreload", "preconnect",
+	"prefetch", "preload", "stylesheet",
+}
+
+// headCollector gathers the head entries declared in a tree, keeping only
+// the last entry for each key, in the position of the first
+type headCollector struct {
+	title   *RawNode
+	entries []*RawNode
+	keys    map[string]int
+
+	// styles is the CSS of each distinct Stylesheet, in order
+	styles []string
+}
+
+func newHeadCollector() *headCollector {
+	return &headCollector{keys: make(map[string]int)}
+}
+
+func (h *headCollector) collect(nodes []Node) {
+	for _, c := range nodes {
+		n := c.GetNode()
+		if n.hide {
+			continue
+		}
+
+		if n.nodeType == headNode {
+			for _, entry := range n.children {
+				h.add(entry.GetNode())
+			}
+			continue
+		}
+
+		h.collect(n.children)
+	}
+}
+
+// addHead adds the children given to Document.Head, so entries with a key
+// are deduplicated along with the ones declared in the tree. Those are
+// collected afterwards, so they win over the document's own.
+func (h *headCollector) addHead(nodes []Node) {
+	for _, c := range nodes {
+		n := c.GetNode()
+		switch {
+		case n.hide:
+		case n.nodeType == fragmentNode:
+			h.addHead(n.children)
+		case n.nodeType == headNode:
+			h.collect([]Node{n})
+		case n.tag == "title":
+			h.title = n
+		case headKey(n) != "":
+			h.add(n)
+		default:
+			// Other children, like scripts, are kept as they are
+			h.entries = append(h.entries, n)
+			h.collect(n.children)
+		}
+	}
+}
+
+func (h *headCollector) add(n *RawNode) {
+	if n.hide {
+		return
+	}
+
+	if n.tag == "title" {
+		h.title = n
+		return
+	}
+
+	if n.tag == "style" {
+		if css := styleText(n); !slices.Contains(h.styles, css) {
+			h.styles = append(h.styles, css)
+		}
+		return
+	}
+
+	key := headKey(n)
+	if i, ok := h.keys[key]; ok && key != "" {
+		h.entries[i] = n
+		return
+	}
+
+	h.keys[key] = len(h.entries)
+	h.entries = append(h.entries, n)
+}
+
+// replace returns the collected entry with the same key as n, removing it
+// from the collected entries, or n itself if there is none
+func (h *headCollector) replace(n *RawNode) *RawNode {
+	key := headKey(n)
+	i, ok := h.keys[key]
+	if !ok || h.entries[i] == nil {
+		return n
+	}
+
+	entry := h.entries[i]
+	h.entries[i] = nil
+	return entry
+}
+
+// nodes returns the collected entries, other than the title, followed by
+// a single <style> with the CSS of every stylesheet
+func (h *headCollector) nodes() []Node {
+	var nodes []Node
+	for _, n := range h.entries {
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+
+	if len(h.styles) > 0 {
+		nodes = append(nodes, Style().HTMLUnsafe(strings.Join(h.styles, "\n")))
+	}
+
+	return nodes
+}
+
+// headKey returns the key that identifies duplicates of a head entry, or
+// an empty string if the entry can't be a duplicate
+func headKey(n *RawNode) string {
+	switch n.tag {
+	case "meta":
+		if n.hasAttr("charset") {
+			return "meta charset"
+		}
+
+		for _, name := range []string{"name", "property", "http-equiv"} {
+			if n.hasAttr(name) {
+				return "meta " + name + "=" + n.attr(name)
+			}
+		}
+	case "link":
+		rel := n.attr("rel")
+		if slices.Contains(multipleLinkRels, rel) {
+			return "link " + rel + " " + n.attr("href")
+		}
+		return "link " + rel
+	}
+
+	return ""
+}
@@ -0,0 +1,112 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestHead(t *testing.T) {
+	t.Run("hoists entries into the document head", func(t *testing.T) {
+		product := func() Node {
+			return Div().Children(
+				HeadTitle("Shoes"),
+				HeadMeta(Meta().Attr("property", "og:title").Attr("content", "Shoes")),
+				HeadLink(Link().Rel("canonical").Href("/shoes")),
+				H1().Text("Shoes"),
+			)
+		}
+
+		root := Document(DocumentOptions{Title: "Store"}).Children(
+			Main().Children(product()),
+		)
+
+		assert.Equal(t, strings.Join([]string{
+			"<!DOCTYPE html>",
+			"<html>",
+			"  <head>",
+			`    <meta charset="utf-8"/>`,
+			`    <meta name="viewport" content="width=device-width, initial-scale=1"/>`,
+			"    <title>Shoes</title>",
+			`    <meta property="og:title" content="Shoes"/>`,
+			`    <link rel="canonical" href="/shoes"/>`,
+			"  </head>",
+			"  <body>",
+			"    <main>",
+			"      <div>",
+			"        <h1>Shoes</h1>",
+			"      </div>",
+			"    </main>",
+			"  </body>",
+			"</html>",
+		}, "\n"), root.ToHTMLPretty())
+	})
+
+	t.Run("deduplicates entries with the last one winning", func(t *testing.T) {
+		root := Document(DocumentOptions{}).
+			Head(
+				HeadMeta(Meta().Name("description").Attr("content", "Default")),
+				HeadLink(Link().Rel("stylesheet").Href("/main.css")),
+			).
+			Children(
+				HeadTitle("First"),
+				HeadMeta(Meta().Name("viewport").Attr("content", "width=500")),
+				HeadLink(Link().Rel("stylesheet").Href("/page.css")),
+				HeadLink(Link().Rel("stylesheet").Href("/main.css")),
+				HeadMeta(Meta().Name("description").Attr("content", "Page")),
+				HeadTitle("Second"),
+			)
+
+		assert.Equal(t, `<!DOCTYPE html><html><head>`+
+			`<meta charset="utf-8"/>`+
+			`<meta name="viewport" content="width=500"/>`+
+			`<title>Second</title>`+
+			`<meta name="description" content="Page"/>`+
+			`<link rel="stylesheet" href="/main.css"/>`+
+			`<link rel="stylesheet" href="/page.css"/>`+
+			`</head><body></body></html>`, root.ToHTML())
+	})
+
+	t.Run("deduplicates the document's own head children", func(t *testing.T) {
+		root := Document(DocumentOptions{Title: "Default"}).
+			Head(
+				Title().Text("Store"),
+				Link().Rel("canonical").Href("/a"),
+				Script().Src("/app.js"),
+				Meta().Name("viewport").Attr("content", "width=500"),
+			).
+			Children(
+				Div().Children(HeadLink(Link().Rel("canonical").Href("/b"))),
+			)
+
+		assert.Equal(t, `<!DOCTYPE html><html><head>`+
+			`<meta charset="utf-8"/>`+
+			`<meta name="viewport" content="width=500"/>`+
+			`<title>Store</title>`+
+			`<link rel="canonical" href="/b"/>`+
+			`<script src="/app.js"></script>`+
+			`</head><body><div></div></body></html>`, root.ToHTML())
+	})
+
+	t.Run("skips hidden entries", func(t *testing.T) {
+		root := Document(DocumentOptions{Title: "Default"}).Children(
+			Div().If(false).Children(HeadTitle("Hidden")),
+			HeadLink(Link().Rel("canonical").Href("/").If(false)),
+		)
+		assert.Equal(t, `<!DOCTYPE html><html><head>`+
+			`<meta charset="utf-8"/>`+
+			`<meta name="viewport" content="width=device-width, initial-scale=1"/>`+
+			`<title>Default</title>`+
+			`</head><body></body></html>`, root.ToHTML())
+	})
+
+	t.Run("renders nothing outside of a document", func(t *testing.T) {
+		root := Div().Children(HeadTitle("Shoes"), Text("Hi"))
+		assert.Equal(t, "<div>Hi</div>", root.ToHTML())
+		assert.Equal(t, "<div>Hi</div>", root.ToHTMLPretty())
+		assert.Equal(t, "Hi", root.ToText())
+		assert.Equal(t, "<div>Hi</div>", root.ToHTMLMinified(MinifyOptions{}))
+	})
+}
@@ -0,0 +1,170 @@
+package hagl
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Swap is how htmx swaps the content of a response into the page, used by
+// HxSwap and HxSwapOOB. Modifiers can be added, like
+// Swap("innerHTML transition:true").
+type Swap string
+
+const (
+	SwapInnerHTML   Swap = "innerHTML"
+	SwapOuterHTML   Swap = "outerHTML"
+	SwapBeforeBegin Swap = "beforebegin"
+	SwapAfterBegin  Swap = "afterbegin"
+	SwapBeforeEnd   Swap = "beforeend"
+	SwapAfterEnd    Swap = "afterend"
+	SwapDelete      Swap = "delete"
+	SwapNone        Swap = "none"
+)
+
+// HxGet issues a GET request to the URL when the element is triggered
+func (rn *RawNode) HxGet(url string) Node {
+	return rn.Attr("hx-get", url)
+}
+
+// HxPost issues a POST request to the URL when the element is triggered
+func (rn *RawNode) HxPost(url string) Node {
+	return rn.Attr("hx-post", url)
+}
+
+// HxTarget sets the element that the response is swapped into, like "#list"
+// or "closest tr"
+func (rn *RawNode) HxTarget(selector string) Node {
+	return rn.Attr("hx-target", selector)
+}
+
+// HxSwap sets how the response is swapped into the target
+func (rn *RawNode) HxSwap(swap Swap) Node {
+	return rn.Attr("hx-swap", string(swap))
+}
+
+// HxTrigger sets the event that triggers the request, like "click" or
+// "keyup changed delay:500ms"
+func (rn *RawNode) HxTrigger(trigger string) Node {
+	return rn.Attr("hx-trigger", trigger)
+}
+
+// HxSwapOOB marks the element to be swapped "out of band" into the element
+// with the same ID, when it's part of a response. An empty swap replaces
+// the element.
+func (rn *RawNode) HxSwapOOB(swap Swap) Node {
+	if swap == "" {
+		swap = "true"
+	}
+	return rn.Attr("hx-swap-oob", string(swap))
+}
+
+// IsHTMXRequest returns whether the request was made by htmx
+func IsHTMXRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// HTMXPartialOptions configures HTMXPartialWith
+type HTMXPartialOptions struct {
+	// Swap is how the requests swap the response into the target. With
+	// SwapOuterHTML, the target element itself is returned, so it keeps its
+	// tag and attributes. Otherwise, only its content is.
+	Swap Swap
+}
+
+// DefaultHTMXPartialOptions returns the options used by HTMXPartial, for
+// requests that use the default innerHTML swap
+func DefaultHTMXPartialOptions() HTMXPartialOptions {
+	return HTMXPartialOptions{Swap: SwapInnerHTML}
+}
+
+// HTMXPartial returns the part of a page that an htmx request asked for.
+// For requests targeting an element by ID, that's the content of the
+// element with the ID, followed by the elements in the page marked with
+// HxSwapOOB. Otherwise, it's the whole page.
+//
+// Since the same URL returns different responses, set "Vary: HX-Request"
+// on responses that may be cached.
+//
+//	Handler(func(r *http.Request) (Node, error) {
+//		return HTMXPartial(r, contactsPage()), nil
+//	})
+func HTMXPartial(r *http.Request, page Node) Node {
+	return HTMXPartialWith(r, page, DefaultHTMXPartialOptions())
+}
+
+// HTMXPartialWith is the same as HTMXPartial, but accepts options. Use it
+// for requests that replace the target element with SwapOuterHTML:
+//
+//	HTMXPartialWith(r, contactsPage(), HTMXPartialOptions{Swap: SwapOuterHTML})
+func HTMXPartialWith(r *http.Request, page Node, opts HTMXPartialOptions) Node {
+	if !IsHTMXRequest(r) || r.Header.Get("HX-Target") == "" {
+		return page
+	}
+
+	// Components render a new tree each time, so render them once up front
+	// to be able to compare nodes
+	root := page.GetNode().clone()
+	found := FindByID(root, r.Header.Get("HX-Target"))
+	if found == nil {
+		return page
+	}
+
+	target := found.GetNode()
+	partial := Fragment()
+	if style := strings.Fields(string(opts.Swap)); len(style) > 0 && Swap(style[0]) == SwapOuterHTML {
+		partial.Children(target)
+	} else {
+		partial.Children(target.children...)
+	}
+	eachOutOfBand(root, target, func(n *RawNode) {
+		partial.Children(n)
+	})
+
+	return partial
+}
+
+// FindByID returns the visible element in the tree with the given ID, or nil
+// if there isn't one
+func FindByID(node Node, id string) Node {
+	var found *RawNode
+
+	var find func(n *RawNode)
+	find = func(n *RawNode) {
+		if found != nil || n.hide || n.nodeType == headNode {
+			return
+		}
+
+		if n.nodeType == tagNode && n.attr("id") == id {
+			found = n
+			return
+		}
+
+		for _, c := range n.children {
+			find(c.GetNode())
+		}
+	}
+	find(node.GetNode())
+
+	if found == nil {
+		return nil
+	}
+	return found
+}
+
+// eachOutOfBand calls fn with every visible element marked with HxSwapOOB,
+// without descending into them or the target, whose content is already
+// part of the response
+func eachOutOfBand(n, target *RawNode, fn func(n *RawNode)) {
+	if n.hide || n == target {
+		return
+	}
+
+	if n.nodeType == tagNode && n.hasAttr("hx-swap-oob") {
+		fn(n)
+		return
+	}
+
+	for _, c := range n.children {
+		eachOutOfBand(c.GetNode(), target, fn)
+	}
+}
@@ -0,0 +1,84 @@
+package hagl_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestHTMX(t *testing.T) {
+	t.Run("adds attributes", func(t *testing.T) {
+		root := Button().
+			HxPost("/contacts").
+			HxTarget("#contacts").
+			HxSwap(SwapBeforeEnd).
+			HxTrigger("click once").
+			Text("Add")
+		assert.Equal(t, `<button hx-post="/contacts" hx-target="#contacts" hx-swap="beforeend" hx-trigger="click once">Add</button>`, root.ToHTML())
+
+		assert.Equal(t, `<div id="count" hx-swap-oob="true"></div>`, Div().ID("count").HxSwapOOB("").ToHTML())
+		assert.Equal(t, `<div hx-get="/more" hx-swap-oob="beforeend:#list"></div>`,
+			Div().HxGet("/more").HxSwapOOB(Swap("beforeend:#list")).ToHTML())
+	})
+
+	page := func() Node {
+		return Document(DocumentOptions{Title: "Contacts"}).Children(
+			Header().Children(Span().ID("count").HxSwapOOB("").Text("2 contacts")),
+			Main().Children(
+				Ul().ID("contacts").Children(
+					Li().Text("Alice"),
+					Li().Text("Bob"),
+				),
+			),
+		)
+	}
+
+	request := func(headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+
+	t.Run("renders the target and out of band elements", func(t *testing.T) {
+		r := request(map[string]string{"HX-Request": "true", "HX-Target": "contacts"})
+		assert.True(t, IsHTMXRequest(r))
+		assert.Equal(t, `<li>Alice</li><li>Bob</li><span id="count" hx-swap-oob="true">2 contacts</span>`,
+			HTMXPartial(r, page()).ToHTML())
+	})
+
+	t.Run("renders the target element for outerHTML swaps", func(t *testing.T) {
+		r := request(map[string]string{"HX-Request": "true", "HX-Target": "contacts"})
+		assert.Equal(t, `<ul id="contacts"><li>Alice</li><li>Bob</li></ul><span id="count" hx-swap-oob="true">2 contacts</span>`,
+			HTMXPartialWith(r, page(), HTMXPartialOptions{Swap: SwapOuterHTML}).ToHTML())
+		assert.Equal(t, `<ul id="contacts"><li>Alice</li><li>Bob</li></ul><span id="count" hx-swap-oob="true">2 contacts</span>`,
+			HTMXPartialWith(r, page(), HTMXPartialOptions{Swap: Swap("outerHTML transition:true")}).ToHTML())
+		assert.Equal(t, HTMXPartial(r, page()).ToHTML(),
+			HTMXPartialWith(r, page(), DefaultHTMXPartialOptions()).ToHTML())
+	})
+
+	t.Run("renders the full page otherwise", func(t *testing.T) {
+		full := page().ToHTML()
+
+		r := request(nil)
+		assert.False(t, IsHTMXRequest(r))
+		assert.Equal(t, full, HTMXPartial(r, page()).ToHTML())
+
+		r = request(map[string]string{"HX-Request": "true"})
+		assert.Equal(t, full, HTMXPartial(r, page()).ToHTML())
+
+		r = request(map[string]string{"HX-Request": "true", "HX-Target": "missing"})
+		assert.Equal(t, full, HTMXPartial(r, page()).ToHTML())
+	})
+
+	t.Run("finds elements by id", func(t *testing.T) {
+		assert.Equal(t, `<ul id="contacts"><li>Alice</li><li>Bob</li></ul>`, FindByID(page(), "contacts").ToHTML())
+		assert.Nil(t, FindByID(page(), "missing"))
+		assert.Nil(t, FindByID(Div().Children(P().ID("hidden").If(false)), "hidden"))
+	})
+}
@@ -0,0 +1,161 @@
+package hagl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// StatusError is an error that should be responded to with a specific HTTP
+// status, like 404 for a missing page. Errors without one get a 500.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+// Error creates an error that responds with the given HTTP status
+func Error(status int, err error) error {
+	return &StatusError{Status: status, Err: err}
+}
+
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// RenderOptions configures RenderWith and HandlerWith
+type RenderOptions struct {
+	// ErrorPage creates the page for an error, which is rendered with the
+	// given status. Defaults to DefaultErrorPage.
+	ErrorPage func(status int, err error) Node
+
+	// OnError is called with every error handled by HandlerWith, like for
+	// logging. It's optional.
+	OnError func(r *http.Request, err error)
+
+	// Strict renders with StrictHTML, so markup that Validate finds problems
+	// with is handled like any other error. It's meant for development and
+	// tests, since validating is slower than rendering.
+	Strict bool
+}
+
+// DefaultRenderOptions returns the options used by Render and Handler
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{ErrorPage: DefaultErrorPage}
+}
+
+// DefaultErrorPage is a plain page with the status text, like "Not Found".
+// The error itself isn't shown, since it may contain private details.
+func DefaultErrorPage(status int, _ error) Node {
+	text := http.StatusText(status)
+	if text == "" {
+		text = "Error " + strconv.Itoa(status)
+	}
+
+	return Document(DocumentOptions{Lang: "en", Title: text}).Children(
+		H1().Text(text),
+	)
+}
+
+// Render writes the node as an HTML response with the given status, using
+// DefaultRenderOptions
+func Render(w http.ResponseWriter, status int, node Node) error {
+	return RenderWith(w, status, node, DefaultRenderOptions())
+}
+
+// RenderWith writes the node as an HTML response with the given status. The
+// node is rendered before anything is written, so if rendering panics, the
+// error page is written instead with a 500 status. Any error from rendering
+// or writing is returned.
+func RenderWith(w http.ResponseWriter, status int, node Node, opts RenderOptions) error {
+	html, err := renderResponse(node, opts.Strict)
+	if err != nil {
+		status = errorStatus(err)
+		page, pageErr := renderResponse(opts.errorPage(status, err), false)
+		if pageErr != nil {
+			http.Error(w, http.StatusText(status), status)
+			return err
+		}
+		html = page
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, writeErr := io.WriteString(w, html); err == nil {
+		err = writeErr
+	}
+
+	return err
+}
+
+// Handler creates an http.Handler that renders the node returned by fn with
+// a 200 status, using DefaultRenderOptions. If fn returns an error, the
+// error page is rendered instead.
+//
+//	http.Handle("/", Handler(func(r *http.Request) (Node, error) {
+//		return Document(DocumentOptions{Title: "Home"}).Children(H1().Text("Hi")), nil
+//	}))
+func Handler(fn func(r *http.Request) (Node, error)) http.Handler {
+	return HandlerWith(fn, DefaultRenderOptions())
+}
+
+// HandlerWith is the same as Handler, but accepts options
+func HandlerWith(fn func(r *http.Request) (Node, error), opts RenderOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node, err := fn(r)
+		if err == nil {
+			err = RenderWith(w, http.StatusOK, node, opts)
+		} else {
+			status := errorStatus(err)
+			if renderErr := RenderWith(w, status, opts.errorPage(status, err), opts); renderErr != nil {
+				err = fmt.Errorf("%w (rendering error page: %v)", err, renderErr)
+			}
+		}
+
+		if err != nil && opts.OnError != nil {
+			opts.OnError(r, err)
+		}
+	})
+}
+
+func (opts RenderOptions) errorPage(status int, err error) Node {
+	if opts.ErrorPage == nil {
+		return DefaultErrorPage(status, err)
+	}
+	return opts.ErrorPage(status, err)
+}
+
+// renderResponse renders the node as HTML, turning a panic into an error
+func renderResponse(node Node, strict bool) (html string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("rendering panicked: %v", r)
+		}
+	}()
+
+	if node == nil {
+		return "", nil
+	}
+
+	if strict {
+		return StrictHTML(node)
+	}
+
+	return node.ToHTML(), nil
+}
+
+func errorStatus(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Status
+	}
+	return http.StatusInternalServerError
+}
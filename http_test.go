@@ -0,0 +1,101 @@
+package hagl_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestRender(t *testing.T) {
+	t.Run("writes html with status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := Render(w, http.StatusCreated, P().Text("Created"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "<p>Created</p>", w.Body.String())
+	})
+
+	t.Run("renders the error page when rendering panics", func(t *testing.T) {
+		broken := NewComponent(func(children []Node) Node {
+			panic("boom")
+		})
+
+		w := httptest.NewRecorder()
+		err := RenderWith(w, http.StatusOK, Div().Children(broken()), RenderOptions{
+			ErrorPage: func(status int, err error) Node {
+				return P().Textf("%d: %s", status, err)
+			},
+		})
+		assert.EqualError(t, err, "rendering panicked: boom")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "<p>500: rendering panicked: boom</p>", w.Body.String())
+	})
+
+	t.Run("renders the error page for invalid markup when strict", func(t *testing.T) {
+		opts := DefaultRenderOptions()
+		opts.Strict = true
+
+		w := httptest.NewRecorder()
+		err := RenderWith(w, http.StatusOK, P().Children(Div()), opts)
+		assert.EqualError(t, err, "p > div: <div> isn't allowed in <p>")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		w = httptest.NewRecorder()
+		assert.NoError(t, RenderWith(w, http.StatusOK, Div().Children(P()), opts))
+		assert.Equal(t, "<div><p></p></div>", w.Body.String())
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("renders the node", func(t *testing.T) {
+		h := Handler(func(r *http.Request) (Node, error) {
+			return P().Text("Hello " + r.URL.Query().Get("name")), nil
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?name=Yaak", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "<p>Hello Yaak</p>", w.Body.String())
+	})
+
+	t.Run("renders the default error page", func(t *testing.T) {
+		h := Handler(func(r *http.Request) (Node, error) {
+			return nil, Error(http.StatusNotFound, errors.New("no user 123"))
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/123", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "<title>Not Found</title>")
+		assert.Contains(t, w.Body.String(), "<h1>Not Found</h1>")
+		assert.NotContains(t, w.Body.String(), "no user 123")
+	})
+
+	t.Run("uses the configured error page", func(t *testing.T) {
+		var handled []error
+		h := HandlerWith(func(r *http.Request) (Node, error) {
+			return nil, errors.New("database is down")
+		}, RenderOptions{
+			ErrorPage: func(status int, err error) Node {
+				return Div().Class("error").Textf("Oops (%d)", status)
+			},
+			OnError: func(r *http.Request, err error) {
+				handled = append(handled, err)
+			},
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, `<div class="error">Oops (500)</div>`, w.Body.String())
+		assert.Len(t, handled, 1)
+		assert.EqualError(t, handled[0], "database is down")
+	})
+}
@@ -0,0 +1,195 @@
+package hagl
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// InlineCSS applies a stylesheet to the style attributes of the elements in
+// a tree, for HTML email where clients ignore <style> elements. It supports
+// type, universal, class, ID and attribute selectors, joined by descendant
+// and child combinators, and follows the rules of specificity and
+// !important.
+//
+// Rules that can't be inlined, like media queries and selectors with
+// pseudo-classes, are kept in a <style> in the <head>, or at the start of
+// the tree if it has no <head>. The node passed in isn't modified.
+func InlineCSS(node Node, css string) (Node, error) {
+	rules, err := parseCSSRules(css)
+	if err != nil {
+		return nil, fmt.Errorf("parsing css: %w", err)
+	}
+
+	var (
+		in       inliner
+		retained []string
+	)
+
+	for _, r := range rules {
+		if !r.hasBlock || strings.HasPrefix(r.prelude, "@") {
+			retained = append(retained, r.String())
+			continue
+		}
+
+		var kept []string
+		decls := parseCSSDeclarations(r.block)
+		for _, sel := range splitCSSList(r.prelude) {
+			s, err := parseSelector(sel)
+			if err == errUnsupportedSelector {
+				kept = append(kept, sel)
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			in.rules = append(in.rules, inlineRule{
+				selector: s,
+				decls:    decls,
+				order:    len(in.rules),
+			})
+		}
+
+		if len(kept) > 0 {
+			r.prelude = strings.Join(kept, ", ")
+			retained = append(retained, r.String())
+		}
+	}
+
+	root := node.GetNode().clone()
+	in.walk(root, nil)
+
+	if len(retained) == 0 {
+		return root, nil
+	}
+
+	style := Style().HTMLUnsafe(strings.Join(retained, "\n"))
+	if head := findTag(root, "head"); head != nil {
+		head.Children(style)
+		return root, nil
+	}
+
+	return Fragment().Children(style, root), nil
+}
+
+type inlineRule struct {
+	selector *cssSelector
+	decls    []cssDeclaration
+
+	// order is the position of the rule in the stylesheet, which breaks
+	// ties between rules with the same specificity
+	order int
+}
+
+type inliner struct {
+	rules []inlineRule
+}
+
+func (in *inliner) walk(n *RawNode, ancestors []*RawNode) {
+	if n.hide || slices.Contains(invisibleEls, n.tag) {
+		return
+	}
+
+	if n.nodeType == tagNode {
+		in.apply(n, ancestors)
+		ancestors = append(ancestors[:len(ancestors):len(ancestors)], n)
+	}
+
+	if n.nodeType == tagNode || n.nodeType == fragmentNode {
+		for _, c := range n.children {
+			in.walk(c.GetNode(), ancestors)
+		}
+	}
+}
+
+// apply sets the declarations of the rules that match n on its style
+// attribute. Declarations already in the style attribute win over those
+// from the stylesheet, unless they're !important.
+func (in *inliner) apply(n *RawNode, ancestors []*RawNode) {
+	var matched []inlineRule
+	for _, r := range in.rules {
+		if r.selector.matches(n, ancestors) {
+			matched = append(matched, r)
+		}
+	}
+
+	if len(matched) == 0 {
+		return
+	}
+
+	type match struct {
+		decl cssDeclaration
+		rule inlineRule
+	}
+
+	var matches []match
+	for _, r := range matched {
+		for _, d := range r.decls {
+			matches = append(matches, match{decl: d, rule: r})
+		}
+	}
+
+	// Apply in order of precedence, so the declarations that win are last
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.decl.important != b.decl.important {
+			return b.decl.important
+		}
+		if a.rule.selector.specificity != b.rule.selector.specificity {
+			return lessSpecific(a.rule.selector.specificity, b.rule.selector.specificity)
+		}
+		return a.rule.order < b.rule.order
+	})
+
+	var (
+		styles    = CSS()
+		important = make(map[string]bool)
+	)
+
+	for _, m := range matches {
+		// Inline declarations are never marked !important, so media
+		// queries in the retained stylesheet can still override them
+		d := m.decl
+		important[d.name] = d.important
+		d.important = false
+		styles.set(d)
+	}
+
+	for _, d := range parseStyles(n.attr("style")).decls {
+		if important[d.name] && !d.important {
+			continue
+		}
+		styles.set(d)
+	}
+
+	n.Attr("style", styles.String())
+}
+
+func lessSpecific(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// findTag returns the first visible element in the tree with the given tag
+func findTag(n *RawNode, tag string) *RawNode {
+	if n.hide {
+		return nil
+	}
+
+	if n.nodeType == tagNode && n.tag == tag {
+		return n
+	}
+
+	for _, c := range n.children {
+		if found := findTag(c.GetNode(), tag); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestInlineCSS(t *testing.T) {
+	t.Run("applies matching rules", func(t *testing.T) {
+		root := Div().Class("email").Children(
+			H1().Text("Hello"),
+			P().Class("lead").Children(
+				A().Href("https://yaak.app").Text("Yaak"),
+			),
+			P().ID("footer").Attr("data-role", "note").Text("Bye"),
+		)
+
+		inlined, err := InlineCSS(root, `
+			h1 { font-size: 24px }
+			.email p { margin: 0 }
+			.email > .lead a { color: blue }
+			#footer { color: gray }
+			[data-role="note"] { font-size: 12px }
+			a[href^="https:"] { text-decoration: none }
+			* { font-family: sans-serif }
+		`)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Join([]string{
+			`<div class="email" style="font-family:sans-serif">`,
+			`<h1 style="font-family:sans-serif;font-size:24px">Hello</h1>`,
+			`<p class="lead" style="font-family:sans-serif;margin:0">`,
+			`<a href="https://yaak.app" style="font-family:sans-serif;text-decoration:none;color:blue">Yaak</a>`,
+			`</p>`,
+			`<p id="footer" data-role="note" style="font-family:sans-serif;font-size:12px;margin:0;color:gray">Bye</p>`,
+			`</div>`,
+		}, ""), inlined.ToHTML())
+
+		// The original tree is untouched
+		assert.NotContains(t, root.ToHTML(), "style=")
+	})
+
+	t.Run("follows specificity, order and importance", func(t *testing.T) {
+		root := Div().Children(
+			P().ID("intro").Class("note").Text("A"),
+			P().Class("note").StyleProperty("color", "black").StyleProperty("margin", "1px").Text("B"),
+		)
+
+		inlined, err := InlineCSS(root, `
+			#intro { color: red }
+			p.note { color: green }
+			.note { color: blue; margin: 0 !important }
+			p { color: orange }
+		`)
+		assert.NoError(t, err)
+		assert.Equal(t, `<div>`+
+			`<p id="intro" class="note" style="color:red;margin:0">A</p>`+
+			`<p class="note" style="color:black;margin:0">B</p>`+
+			`</div>`, inlined.ToHTML())
+	})
+
+	t.Run("keeps rules that can't be inlined", func(t *testing.T) {
+		root := Document(DocumentOptions{}).Children(A().Href("/").Text("Home"))
+
+		inlined, err := InlineCSS(root, `
+			a, a:hover { color: blue }
+			@media (max-width: 600px) { a { color: red !important } }
+		`)
+		assert.NoError(t, err)
+
+		html := inlined.ToHTML()
+		assert.Contains(t, html, "<style>a:hover { color: blue }\n"+
+			"@media (max-width: 600px) { a { color: red !important } }</style></head>")
+		assert.Contains(t, html, `<a href="/" style="color:blue">Home</a>`)
+	})
+
+	t.Run("adds retained rules before a tree without a head", func(t *testing.T) {
+		inlined, err := InlineCSS(P().Text("Hi"), "p::first-line { color: red }")
+		assert.NoError(t, err)
+		assert.Equal(t, "<style>p::first-line { color: red }</style><p>Hi</p>", inlined.ToHTML())
+	})
+
+	t.Run("returns errors for invalid css", func(t *testing.T) {
+		_, err := InlineCSS(P(), "p { color: red")
+		assert.EqualError(t, err, `parsing css: unclosed block at "p { color: red"`)
+
+		_, err = InlineCSS(P(), "p > { color: red }")
+		assert.EqualError(t, err, `invalid selector "p >"`)
+
+		_, err = InlineCSS(P(), "a[href { color: red }")
+		assert.EqualError(t, err, `invalid selector "a[href": expected ]`)
+	})
+}
@@ -0,0 +1,116 @@
+package hagl
+
+import (
+	"net/http"
+)
+
+// ContentRegion is the region that the children of a page are added to
+const ContentRegion = "content"
+
+// Regions are the named parts of a page, like "title", "sidebar", "content"
+// and "scripts", which its layout places on the page
+type Regions map[string]Node
+
+// Get returns the region with the given name, or an empty fragment if the
+// page didn't supply it
+func (r Regions) Get(name string) Node {
+	if n, ok := r[name]; ok && n != nil {
+		return n
+	}
+	return Fragment()
+}
+
+// Has returns whether the page supplied the region
+func (r Regions) Has(name string) bool {
+	return r[name] != nil
+}
+
+// With returns a copy of the regions with one region replaced, for nested
+// layouts that wrap a region of the page before passing it to their parent
+func (r Regions) With(name string, n Node) Regions {
+	c := make(Regions, len(r)+1)
+	for k, v := range r {
+		c[k] = v
+	}
+	c[name] = n
+	return c
+}
+
+// Layout places the regions of a page. Layouts can be nested, where each
+// one fills in or wraps regions before passing them to its parent.
+//
+//	var base = NewLayout(func(r Regions) Node {
+//		return Document(DocumentOptions{Lang: "en"}).Children(
+//			HeadTitle(r.Get("title").ToText()).If(r.Has("title")),
+//			Main().Children(r.Get(ContentRegion)),
+//		)
+//	})
+//
+//	var settings = NewNestedLayout(base, func(r Regions) Regions {
+//		return r.With(ContentRegion, Div().Class("settings").Children(
+//			Nav().Children(settingsLinks()),
+//			r.Get(ContentRegion),
+//		))
+//	})
+//
+//	page := settings.Page(Regions{"title": Text("Profile")}).Children(profileForm())
+type Layout struct {
+	parent *Layout
+	render func(r Regions) Node
+	fill   func(r Regions) Regions
+}
+
+// NewLayout creates a layout that renders the regions of a page
+func NewLayout(render func(r Regions) Node) *Layout {
+	return &Layout{render: render}
+}
+
+// NewNestedLayout creates a layout inside parent. It returns the regions
+// to pass to the parent, given the regions of the page.
+func NewNestedLayout(parent *Layout, fill func(r Regions) Regions) *Layout {
+	return &Layout{parent: parent, fill: fill}
+}
+
+// Render renders the regions with the layout and its parents
+func (l *Layout) Render(r Regions) Node {
+	if r == nil {
+		r = Regions{}
+	}
+
+	if l.parent != nil {
+		return l.parent.Render(l.fill(r))
+	}
+
+	return l.render(r)
+}
+
+// Page creates a page with the layout. Children added to the page are
+// appended to its ContentRegion, and attributes are added to the root of
+// the layout, like with NewComponent.
+func (l *Layout) Page(regions Regions) Node {
+	return NewComponent(func(children []Node) Node {
+		if len(children) == 0 {
+			return l.Render(regions)
+		}
+
+		content := append([]Node{regions.Get(ContentRegion)}, children...)
+		return l.Render(regions.With(ContentRegion, Fragment().Children(content...)))
+	})()
+}
+
+// Handler creates an http.Handler that renders the regions returned by fn
+// as a page with the layout, like Handler
+func (l *Layout) Handler(fn func(r *http.Request) (Regions, error)) http.Handler {
+	return l.HandlerWith(fn, DefaultRenderOptions())
+}
+
+// HandlerWith is the same as Handler, but accepts options
+func (l *Layout) HandlerWith(fn func(r *http.Request) (Regions, error), opts RenderOptions) http.Handler {
+	return HandlerWith(func(r *http.Request) (Node, error) {
+		regions, err := fn(r)
+		if err != nil {
+			return nil, err
+		}
+		return l.Page(regions), nil
+	}, opts)
+}
@@ -0,0 +1,101 @@
+package hagl_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestLayout(t *testing.T) {
+	base := NewLayout(func(r Regions) Node {
+		return Document(DocumentOptions{Lang: "en"}).Children(
+			HeadTitle(r.Get("title").ToText()).If(r.Has("title")),
+			Main().Children(r.Get(ContentRegion)),
+			r.Get("scripts"),
+		)
+	})
+
+	shell := NewNestedLayout(base, func(r Regions) Regions {
+		return r.With(ContentRegion, Fragment().Children(
+			Nav().Text("App"),
+			r.Get(ContentRegion),
+		))
+	})
+
+	settings := NewNestedLayout(shell, func(r Regions) Regions {
+		return r.With(ContentRegion, Div().Class("settings").Children(
+			Aside().Children(r.Get("sidebar")),
+			Section().Children(r.Get(ContentRegion)),
+		))
+	})
+
+	t.Run("renders nested layouts", func(t *testing.T) {
+		page := settings.Page(Regions{
+			"title":   Text("Profile"),
+			"sidebar": A().Href("/settings/profile").Text("Profile"),
+			"scripts": Script().Src("/profile.js"),
+		}).Children(H1().Text("Profile"))
+
+		assert.Equal(t, strings.Join([]string{
+			"<!DOCTYPE html>",
+			`<html lang="en">`,
+			"  <head>",
+			`    <meta charset="utf-8"/>`,
+			`    <meta name="viewport" content="width=device-width, initial-scale=1"/>`,
+			"    <title>Profile</title>",
+			"  </head>",
+			"  <body>",
+			"    <main>",
+			"      <nav>App</nav>",
+			`      <div class="settings">`,
+			"        <aside>",
+			`          <a href="/settings/profile">Profile</a>`,
+			"        </aside>",
+			"        <section>",
+			"          <h1>Profile</h1>",
+			"        </section>",
+			"      </div>",
+			"    </main>",
+			`    <script src="/profile.js"></script>`,
+			"  </body>",
+			"</html>",
+		}, "\n"), page.ToHTMLPretty())
+	})
+
+	t.Run("renders missing regions as nothing", func(t *testing.T) {
+		page := base.Page(nil).Class("home")
+		assert.Equal(t, `<!DOCTYPE html><html lang="en"><head>`+
+			`<meta charset="utf-8"/><meta name="viewport" content="width=device-width, initial-scale=1"/>`+
+			`</head><body class="home"><main></main></body></html>`, page.ToHTML())
+	})
+
+	t.Run("appends children to the content region", func(t *testing.T) {
+		page := base.Page(Regions{ContentRegion: P().Text("First")}).Children(P().Text("Second"))
+		assert.Contains(t, page.ToHTML(), "<main><p>First</p><p>Second</p></main>")
+	})
+
+	t.Run("serves pages", func(t *testing.T) {
+		h := shell.Handler(func(r *http.Request) (Regions, error) {
+			if r.URL.Path != "/" {
+				return nil, Error(http.StatusNotFound, errors.New("missing"))
+			}
+			return Regions{"title": Text("Home"), ContentRegion: H1().Text("Home")}, nil
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "<title>Home</title>")
+		assert.Contains(t, w.Body.String(), "<main><nav>App</nav><h1>Home</h1></main>")
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,399 @@
+package hagl
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// markdownContainers are block elements whose children are rendered as
+// Markdown without anything marking the element itself
+var markdownContainers = []string{
+	"address", "article", "aside", "body", "div", "figcaption", "figure",
+	"footer", "header", "html", "main", "nav", "section",
+}
+
+// markdownInlineContainers are inline elements with no meaning in Markdown
+// whose children are rendered without anything marking the element itself
+var markdownInlineContainers = []string{
+	"bdi", "bdo", "cite", "data", "dfn", "label", "small", "span", "time", "var",
+}
+
+type markdownRenderer struct{}
+
+func (r *markdownRenderer) render(rn *RawNode) string {
+	return strings.Trim(r.blocks([]Node{rn}, "\n\n"), "\n")
+}
+
+// blocks renders sibling nodes, grouping consecutive inline nodes into
+// paragraphs, and joins the resulting blocks with sep
+func (r *markdownRenderer) blocks(nodes []Node, sep string) string {
+	var (
+		out    []string
+		inline strings.Builder
+	)
+
+	flush := func() {
+		if s := r.paragraph(inline.String()); s != "" {
+			out = append(out, s)
+		}
+		inline.Reset()
+	}
+
+	eachVisible(nodes, func(n *RawNode) {
+		if !isTextBlock(n) {
+			r.inline(n, &inline)
+			return
+		}
+
+		flush()
+		if s := r.block(n); s != "" {
+			out = append(out, s)
+		}
+	})
+	flush()
+
+	return strings.Join(out, sep)
+}
+
+func (r *markdownRenderer) block(n *RawNode) string {
+	switch n.tag {
+	case "p":
+		return r.blocks(n.children, "\n\n")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.ReplaceAll(r.blocks(n.children, " "), "\\\n", " ")
+		if text == "" {
+			return ""
+		}
+		level := int(n.tag[1] - '0')
+		return strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\n", " ")
+	case "ul", "ol":
+		return r.list(n)
+	case "li":
+		return r.blocks(n.children, "\n")
+	case "blockquote":
+		lines := strings.Split(r.blocks(n.children, "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case "pre":
+		return r.codeBlock(n)
+	case "hr":
+		return "---"
+	case "table":
+		return r.table(n)
+	}
+
+	if slices.Contains(markdownContainers, n.tag) {
+		return r.blocks(n.children, "\n\n")
+	}
+
+	// No Markdown equivalent, so fall back to HTML
+	return n.ToHTML()
+}
+
+// paragraph collapses whitespace in inline Markdown and escapes
+// characters that would otherwise start a block at the beginning of a line
+func (r *markdownRenderer) paragraph(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.Join(strings.FieldsFunc(line, isHTMLSpace), " ")
+		line = markdownBlockMarker.ReplaceAllString(line, `\$1`)
+		lines[i] = markdownListNumber.ReplaceAllString(line, `$1\$2$3`)
+	}
+
+	text = strings.Trim(strings.Join(lines, "\n"), "\n")
+
+	// A hard break at the end of a paragraph does nothing
+	return strings.TrimSuffix(text, "\\")
+}
+
+var (
+	markdownBlockMarker = regexp.MustCompile(`^([#+\-=])`)
+	markdownListNumber  = regexp.MustCompile(`^(\d+)([.)])(\s|$)`)
+)
+
+func (r *markdownRenderer) list(n *RawNode) string {
+	var (
+		items []string
+		num   = 1
+	)
+
+	if start, err := strconv.Atoi(n.attr("start")); err == nil {
+		num = start
+	}
+
+	eachVisible(n.children, func(c *RawNode) {
+		if c.tag != "li" {
+			if s := r.blocks([]Node{c}, "\n"); s != "" {
+				items = append(items, s)
+			}
+			return
+		}
+
+		marker := "- "
+		if n.tag == "ol" {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+
+		// Items containing paragraphs need blank lines between blocks
+		sep := "\n"
+		eachVisible(c.children, func(child *RawNode) {
+			if child.tag == "p" {
+				sep = "\n\n"
+			}
+		})
+
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(r.blocks(c.children, sep), "\n")
+		for i, line := range lines {
+			if i == 0 {
+				lines[i] = marker + line
+			} else if line != "" {
+				lines[i] = indent + line
+			}
+		}
+
+		items = append(items, strings.Join(lines, "\n"))
+	})
+
+	return strings.Join(items, "\n")
+}
+
+// codeBlock renders a <pre> as a fenced code block, taking the language
+// from a "language-" class on the pre or a <code> inside it
+func (r *markdownRenderer) codeBlock(n *RawNode) string {
+	var b strings.Builder
+	rawText(n, &b)
+	code := strings.TrimRight(strings.TrimPrefix(b.String(), "\n"), "\n")
+
+	lang := markdownLanguage(n)
+	eachVisible(n.children, func(c *RawNode) {
+		if lang == "" && c.tag == "code" {
+			lang = markdownLanguage(c)
+		}
+	})
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+func markdownLanguage(n *RawNode) string {
+	for _, cls := range strings.Fields(n.attr("class")) {
+		if strings.HasPrefix(cls, "language-") {
+			return strings.TrimPrefix(cls, "language-")
+		}
+	}
+	return ""
+}
+
+// table renders a GFM table. The first row is used as the header, since
+// GFM tables can't exist without one.
+func (r *markdownRenderer) table(n *RawNode) string {
+	var (
+		rows    [][]string
+		collect func(nodes []Node)
+	)
+
+	collect = func(nodes []Node) {
+		eachVisible(nodes, func(c *RawNode) {
+			switch c.tag {
+			case "thead", "tbody", "tfoot":
+				collect(c.children)
+			case "tr":
+				var row []string
+				eachVisible(c.children, func(cell *RawNode) {
+					if cell.tag != "td" && cell.tag != "th" {
+						return
+					}
+
+					var b strings.Builder
+					r.children(cell, &b)
+					text := strings.ReplaceAll(r.paragraph(b.String()), "\\\n", "<br>")
+					text = strings.ReplaceAll(text, "\n", " ")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				})
+
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		})
+	}
+	collect(n.children)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = maxInt(columns, len(row))
+	}
+
+	widths := make([]int, columns)
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = maxInt(widths[i], textWidth(cell))
+		}
+	}
+
+	line := func(cells []string) string {
+		cols := make([]string, columns)
+		for i, w := range widths {
+			text := ""
+			if i < len(cells) {
+				text = cells[i]
+			}
+			cols[i] = text + strings.Repeat(" ", maxInt(w, 3)-textWidth(text))
+		}
+		return "| " + strings.Join(cols, " | ") + " |"
+	}
+
+	separator := make([]string, columns)
+	for i, w := range widths {
+		separator[i] = strings.Repeat("-", maxInt(w, 3))
+	}
+
+	out := []string{line(rows[0]), line(separator)}
+	for _, row := range rows[1:] {
+		out = append(out, line(row))
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// inline writes the Markdown for an inline node to b
+func (r *markdownRenderer) inline(n *RawNode, b *strings.Builder) {
+	if n.nodeType == textNode {
+		if n.unsafe {
+			// Markdown allows inline HTML, so raw HTML is kept as-is
+			b.WriteString(n.text)
+		} else {
+			b.WriteString(escapeMarkdown(html.UnescapeString(n.text)))
+		}
+		return
+	}
+
+	switch n.tag {
+	case "br":
+		b.WriteString("\\\n")
+	case "em", "i":
+		b.WriteString(r.delimit(n, "*"))
+	case "strong", "b":
+		b.WriteString(r.delimit(n, "**"))
+	case "del", "s":
+		b.WriteString(r.delimit(n, "~~"))
+	case "code":
+		var code strings.Builder
+		rawText(n, &code)
+		b.WriteString(markdownCode(code.String()))
+	case "a":
+		var text strings.Builder
+		r.children(n, &text)
+
+		href := n.attr("href")
+		if href == "" {
+			b.WriteString(text.String())
+			return
+		}
+
+		b.WriteString("[" + text.String() + "](" + markdownURL(href) + markdownTitle(n) + ")")
+	case "img":
+		alt := escapeMarkdown(n.attr("alt"))
+		b.WriteString("![" + alt + "](" + markdownURL(n.attr("src")) + markdownTitle(n) + ")")
+	default:
+		if slices.Contains(markdownInlineContainers, n.tag) || n.nodeType != tagNode {
+			r.children(n, b)
+			return
+		}
+
+		// No Markdown equivalent, so fall back to HTML
+		b.WriteString(n.ToHTML())
+	}
+}
+
+func (r *markdownRenderer) children(n *RawNode, b *strings.Builder) {
+	eachVisible(n.children, func(c *RawNode) {
+		r.inline(c, b)
+	})
+}
+
+// delimit wraps the inline content of n in delim, moving surrounding
+// whitespace outside of it since Markdown doesn't allow it inside
+func (r *markdownRenderer) delimit(n *RawNode, delim string) string {
+	var b strings.Builder
+	r.children(n, &b)
+
+	text := b.String()
+	trimmed := strings.TrimFunc(text, isHTMLSpace)
+	if trimmed == "" {
+		return text
+	}
+
+	start := strings.Index(text, trimmed)
+	return text[:start] + delim + trimmed + delim + text[start+len(trimmed):]
+}
+
+// markdownCode renders an inline code span, using enough backticks to
+// contain any backticks inside the code
+func markdownCode(code string) string {
+	longest, run := 0, 0
+	for _, c := range code {
+		if c == '`' {
+			run++
+			longest = maxInt(longest, run)
+		} else {
+			run = 0
+		}
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+
+	return fence + code + fence
+}
+
+func markdownURL(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+func markdownTitle(n *RawNode) string {
+	title := n.attr("title")
+	if title == "" {
+		return ""
+	}
+	return ` "` + markdownTitleEscaper.Replace(title) + `"`
+}
+
+var markdownTitleEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"~", `\~`,
+)
+
+// escapeMarkdown escapes characters that have meaning inside Markdown text
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
@@ -0,0 +1,1049 @@
+package hagl
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MarkdownOptions configures FromMarkdown
+type MarkdownOptions struct {
+	// HeadingIDs adds an id attribute to each heading, generated from its
+	// text. Duplicate IDs are suffixed with a number.
+	HeadingIDs bool
+
+	// UnsafeHTML renders HTML found in the source as-is, and allows links
+	// and images with any URL. By default, HTML is escaped and rendered as
+	// text, and only http, https, mailto and relative URLs are allowed, so
+	// links like javascript:alert(1) are rendered without an href.
+	UnsafeHTML bool
+}
+
+// FromMarkdown builds a node tree from CommonMark source, including the GFM
+// table, strikethrough and autolink extensions. The returned node is a
+// Fragment containing one child for each top-level block.
+func FromMarkdown(src string, opts MarkdownOptions) Node {
+	p := &markdownParser{
+		opts: opts,
+		refs: make(map[string]markdownLink),
+		ids:  make(map[string]int),
+	}
+
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(src), "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+
+	return Fragment().Children(p.blocks(p.collectRefs(lines))...)
+}
+
+type markdownLink struct {
+	href  string
+	title string
+}
+
+type markdownParser struct {
+	opts MarkdownOptions
+
+	// refs holds link reference definitions by normalized label
+	refs map[string]markdownLink
+
+	// ids counts the heading IDs that have been generated
+	ids map[string]int
+}
+
+var (
+	mdATXHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetextHeading = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdThematicBreak = regexp.MustCompile(`^ {0,3}((?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdFence         = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+	mdBlockquote    = regexp.MustCompile(`^ {0,3}> ?`)
+	mdListItem      = regexp.MustCompile(`^( {0,3})([-+*]|\d{1,9}[.)])( +|$)`)
+	mdTableDivider  = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdHTMLBlock     = regexp.MustCompile(`^ {0,3}(?:<(?:!--|/?(?:address|article|aside|blockquote|body|details|dialog|dd|div|dl|dt|fieldset|figcaption|figure|footer|form|h[1-6]|head|header|hr|html|li|main|nav|ol|p|pre|script|section|style|summary|table|tbody|td|tfoot|th|thead|tr|ul)(?:[\s/>]|$))|</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>[ \t]*$)`)
+	mdReference     = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*<?([^\s>]+)>?(?:[ \t]+("[^"]*"|'[^']*'|\([^)]*\)))?[ \t]*$`)
+)
+
+// expandTabs replaces tabs in the indentation of a line with spaces, so
+// indentation can be measured in columns
+func expandTabs(line string) string {
+	var b strings.Builder
+	for i, c := range line {
+		switch c {
+		case ' ':
+			b.WriteByte(' ')
+		case '\t':
+			b.WriteString(strings.Repeat(" ", 4-b.Len()%4))
+		default:
+			return b.String() + line[i:]
+		}
+	}
+	return b.String()
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// collectRefs removes link reference definitions from the source, outside
+// of code blocks, and stores them for use when parsing links
+func (p *markdownParser) collectRefs(lines []string) []string {
+	var (
+		out   []string
+		fence string
+	)
+
+	for _, line := range lines {
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[2]
+			} else if strings.HasPrefix(m[2], fence[:1]) && len(m[2]) >= len(fence) && m[3] == "" {
+				fence = ""
+			}
+		}
+
+		if m := mdReference.FindStringSubmatch(line); m != nil && fence == "" {
+			label := normalizeLabel(m[1])
+			if _, ok := p.refs[label]; !ok {
+				title := m[3]
+				if len(title) >= 2 {
+					title = title[1 : len(title)-1]
+				}
+				// Destinations and titles are unescaped like those of
+				// inline links, before their URLs are checked
+				p.refs[label] = markdownLink{
+					href:  html.UnescapeString(unescapeMarkdown(m[2])),
+					title: html.UnescapeString(unescapeMarkdown(title)),
+				}
+			}
+			continue
+		}
+
+		out = append(out, line)
+	}
+
+	return out
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// startsBlock returns whether a line would interrupt a paragraph
+func (p *markdownParser) startsBlock(line string) bool {
+	if mdATXHeading.MatchString(line) || mdThematicBreak.MatchString(line) ||
+		mdFence.MatchString(line) || mdBlockquote.MatchString(line) {
+		return true
+	}
+
+	if m := mdListItem.FindStringSubmatch(line); m != nil {
+		// Only lists starting at 1 and with content can interrupt a paragraph
+		marker := m[2]
+		empty := isBlank(line[len(m[0]):])
+		return !empty && (strings.ContainsAny(marker, "-+*") || marker[:len(marker)-1] == "1")
+	}
+
+	return p.opts.UnsafeHTML && mdHTMLBlock.MatchString(line)
+}
+
+// blocks parses lines into block nodes
+func (p *markdownParser) blocks(lines []string) []Node {
+	var nodes []Node
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			i++
+		case mdFence.MatchString(line):
+			var n Node
+			n, i = p.fencedCode(lines, i)
+			nodes = append(nodes, n)
+		case indentOf(line) >= 4:
+			var n Node
+			n, i = p.indentedCode(lines, i)
+			nodes = append(nodes, n)
+		case mdATXHeading.MatchString(line):
+			m := mdATXHeading.FindStringSubmatch(line)
+			nodes = append(nodes, p.heading(len(m[1]), m[2]))
+			i++
+		case mdThematicBreak.MatchString(line):
+			nodes = append(nodes, Hr())
+			i++
+		case mdBlockquote.MatchString(line):
+			var n Node
+			n, i = p.blockquote(lines, i)
+			nodes = append(nodes, n)
+		case mdListItem.MatchString(line):
+			var n Node
+			n, i = p.list(lines, i)
+			nodes = append(nodes, n)
+		case p.opts.UnsafeHTML && mdHTMLBlock.MatchString(line):
+			start := i
+			for i < len(lines) && !isBlank(lines[i]) {
+				i++
+			}
+			nodes = append(nodes, UnsafeText(strings.Join(lines[start:i], "\n")))
+		case i+1 < len(lines) && strings.Contains(line, "|") && mdTableDivider.MatchString(lines[i+1]):
+			var n Node
+			n, i = p.table(lines, i)
+			nodes = append(nodes, n)
+		default:
+			var n Node
+			n, i = p.paragraph(lines, i)
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
+
+func (p *markdownParser) paragraph(lines []string, i int) (Node, int) {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+
+		if len(text) > 0 {
+			if m := mdSetextHeading.FindStringSubmatch(line); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				return p.heading(level, strings.Join(text, "\n")), i + 1
+			}
+
+			if p.startsBlock(line) {
+				break
+			}
+		}
+
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+
+	return P().Children(p.inline(strings.Join(text, "\n"))...), i
+}
+
+func (p *markdownParser) heading(level int, text string) Node {
+	el := newTagNode("h" + strconv.Itoa(level))
+	el.Children(p.inline(strings.TrimSpace(text))...)
+
+	if p.opts.HeadingIDs {
+		var b strings.Builder
+		rawText(el, &b)
+		el.ID(p.headingID(b.String()))
+	}
+
+	return el
+}
+
+// headingID generates a unique, URL-friendly ID from the text of a heading
+func (p *markdownParser) headingID(text string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(c) || unicode.IsNumber(c) || c == '-' || c == '_':
+			b.WriteRune(c)
+		case unicode.IsSpace(c):
+			b.WriteRune('-')
+		}
+	}
+
+	id := b.String()
+	if id == "" {
+		id = "section"
+	}
+
+	count := p.ids[id]
+	p.ids[id]++
+	if count > 0 {
+		id += "-" + strconv.Itoa(count)
+	}
+
+	return id
+}
+
+func (p *markdownParser) fencedCode(lines []string, i int) (Node, int) {
+	m := mdFence.FindStringSubmatch(lines[i])
+	indent, fence, info := len(m[1]), m[2], m[3]
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		if c := mdFence.FindStringSubmatch(lines[i]); c != nil && c[2][0] == fence[0] &&
+			len(c[2]) >= len(fence) && c[3] == "" {
+			i++
+			break
+		}
+
+		line := lines[i]
+		line = line[minInt(indent, indentOf(line)):]
+		code = append(code, line)
+	}
+
+	return p.codeBlock(strings.Join(code, "\n"), info), i
+}
+
+func (p *markdownParser) indentedCode(lines []string, i int) (Node, int) {
+	var code []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if !isBlank(line) && indentOf(line) < 4 {
+			break
+		}
+
+		code = append(code, line[minInt(4, indentOf(line)):])
+	}
+
+	// Trailing blank lines are not part of the code
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+
+	return p.codeBlock(strings.Join(code, "\n"), ""), i
+}
+
+func (p *markdownParser) codeBlock(code, info string) Node {
+	el := Code().Text(code)
+	if lang := strings.Fields(info); len(lang) > 0 {
+		el.Class("language-" + html.UnescapeString(lang[0]))
+	}
+
+	return Pre().Children(el)
+}
+
+func (p *markdownParser) blockquote(lines []string, i int) (Node, int) {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if loc := mdBlockquote.FindStringIndex(line); loc != nil {
+			inner = append(inner, line[loc[1]:])
+			continue
+		}
+
+		// Lazy continuation of a paragraph inside the quote
+		if isBlank(line) || len(inner) == 0 || isBlank(inner[len(inner)-1]) || p.startsBlock(line) {
+			break
+		}
+
+		inner = append(inner, line)
+	}
+
+	return Blockquote().Children(p.blocks(inner)...), i
+}
+
+func (p *markdownParser) list(lines []string, i int) (Node, int) {
+	first := mdListItem.FindStringSubmatch(lines[i])
+	marker := first[2]
+	ordered := !strings.ContainsAny(marker, "-+*")
+
+	var list Node
+	if ordered {
+		list = Ol()
+		if start, _ := strconv.Atoi(marker[:len(marker)-1]); start != 1 {
+			list.Attr("start", strconv.Itoa(start))
+		}
+	} else {
+		list = Ul()
+	}
+
+	var (
+		items [][]Node
+		loose bool
+	)
+
+	for i < len(lines) {
+		if !continuesList(lines[i], marker) {
+			break
+		}
+
+		m := mdListItem.FindStringSubmatch(lines[i])
+
+		// Content starts after the marker, unless it's followed by enough
+		// spaces to be indented code
+		contentIndent := len(m[0])
+		if len(m[3]) == 0 || len(m[3]) > 4 {
+			contentIndent = len(m[1]) + len(m[2]) + 1
+		}
+
+		var content []string
+		content = append(content, lines[i][minInt(contentIndent, len(lines[i])):])
+
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			switch {
+			case isBlank(line):
+				content = append(content, "")
+				continue
+			case indentOf(line) >= contentIndent:
+				content = append(content, line[contentIndent:])
+				continue
+			case !isBlank(content[len(content)-1]) && !p.startsBlock(line) && !mdListItem.MatchString(line):
+				// Lazy continuation of a paragraph in the item
+				content = append(content, line)
+				continue
+			}
+			break
+		}
+
+		// Blank lines after the item separate it from the next one
+		trailing := 0
+		for len(content) > 1 && isBlank(content[len(content)-1]) {
+			content = content[:len(content)-1]
+			trailing++
+		}
+
+		if trailing > 0 && i < len(lines) && continuesList(lines[i], marker) {
+			loose = true
+		}
+
+		if hasBlankBetweenBlocks(content) {
+			loose = true
+		}
+
+		items = append(items, p.blocks(content))
+	}
+
+	for _, blocks := range items {
+		li := Li()
+		for _, b := range blocks {
+			// Paragraphs in tight lists are rendered without <p>
+			if n := b.GetNode(); !loose && n.tag == "p" {
+				li.Children(n.children...)
+			} else {
+				li.Children(b)
+			}
+		}
+		list.Children(li)
+	}
+
+	return list, i
+}
+
+// continuesList returns whether line starts an item belonging to the same
+// list as an item with the given marker. Lists end when the bullet
+// character or the delimiter after the number changes.
+func continuesList(line, marker string) bool {
+	m := mdListItem.FindStringSubmatch(line)
+	return m != nil && m[2][len(m[2])-1] == marker[len(marker)-1]
+}
+
+// hasBlankBetweenBlocks returns whether the lines of a list item contain
+// a blank line separating two of its direct children
+func hasBlankBetweenBlocks(lines []string) bool {
+	for i := 1; i < len(lines)-1; i++ {
+		next := lines[i+1]
+		if isBlank(lines[i]) && !isBlank(next) && indentOf(next) == 0 && !mdListItem.MatchString(next) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *markdownParser) table(lines []string, i int) (Node, int) {
+	header := splitTableRow(lines[i])
+	dividers := splitTableRow(lines[i+1])
+
+	aligns := make([]string, len(header))
+	for c := range aligns {
+		if c >= len(dividers) {
+			break
+		}
+
+		d := dividers[c]
+		switch {
+		case strings.HasPrefix(d, ":") && strings.HasSuffix(d, ":"):
+			aligns[c] = "center"
+		case strings.HasPrefix(d, ":"):
+			aligns[c] = "left"
+		case strings.HasSuffix(d, ":"):
+			aligns[c] = "right"
+		}
+	}
+
+	row := func(cells []string, cell func() Node) Node {
+		tr := Tr()
+		for c := range header {
+			el := cell()
+			if aligns[c] != "" {
+				el.StyleProperty("text-align", aligns[c])
+			}
+			if c < len(cells) {
+				el.Children(p.inline(cells[c])...)
+			}
+			tr.Children(el)
+		}
+		return tr
+	}
+
+	table := Table().Children(Thead().Children(row(header, Th)))
+
+	var body []Node
+	for i += 2; i < len(lines); i++ {
+		if isBlank(lines[i]) || p.startsBlock(lines[i]) {
+			break
+		}
+		body = append(body, row(splitTableRow(lines[i]), Td))
+	}
+
+	if len(body) > 0 {
+		table.Children(Tbody().Children(body...))
+	}
+
+	return table, i
+}
+
+// splitTableRow splits a table row into trimmed cells, ignoring escaped
+// pipes and pipes inside code spans
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var (
+		cells  []string
+		cell   strings.Builder
+		inCode bool
+	)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// inlineParser parses the inline content of a single block
+type inlineParser struct {
+	p     *markdownParser
+	src   string
+	nodes []Node
+	text  strings.Builder
+
+	// noCloser is the earliest index from which a search for a closing
+	// delimiter run failed, so the source is only searched once for each
+	// kind of run
+	noCloser map[delimiterRun]int
+}
+
+// delimiterRun is a run of n emphasis characters c
+type delimiterRun struct {
+	c byte
+	n int
+}
+
+func (p *markdownParser) inline(src string) []Node {
+	ip := &inlineParser{p: p, src: src}
+	ip.parse()
+	return ip.nodes
+}
+
+func (ip *inlineParser) flush() {
+	if ip.text.Len() > 0 {
+		ip.nodes = append(ip.nodes, Text(html.UnescapeString(ip.text.String())))
+		ip.text.Reset()
+	}
+}
+
+func (ip *inlineParser) add(n Node) {
+	ip.flush()
+	ip.nodes = append(ip.nodes, n)
+}
+
+func (ip *inlineParser) parse() {
+	src := ip.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			ip.add(Br())
+			i += 2
+		case c == '\\' && i+1 < len(src) && isASCIIPunct(src[i+1]):
+			// Escaped characters must not be decoded as entities
+			ip.text.WriteString(html.EscapeString(string(src[i+1])))
+			i += 2
+		case c == '\n':
+			// Two or more spaces before a line break make it a hard break
+			text := ip.text.String()
+			trimmed := strings.TrimRight(text, " ")
+			ip.text.Reset()
+			ip.text.WriteString(trimmed)
+			if len(text)-len(trimmed) >= 2 {
+				ip.add(Br())
+			} else {
+				ip.text.WriteByte('\n')
+			}
+			i++
+			for i < len(src) && src[i] == ' ' {
+				i++
+			}
+		case c == '`':
+			i = ip.codeSpan(i)
+		case c == '*' || c == '_' || c == '~':
+			i = ip.emphasis(i)
+		case c == '!' && strings.HasPrefix(src[i:], "!["):
+			i = ip.link(i+1, true)
+		case c == '[':
+			i = ip.link(i, false)
+		case c == '<':
+			i = ip.angle(i)
+		case c == 'h' || c == 'w':
+			i = ip.autolink(i)
+		default:
+			ip.text.WriteByte(c)
+			i++
+		}
+	}
+	ip.flush()
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func (ip *inlineParser) codeSpan(i int) int {
+	src := ip.src
+	n := runLength(src, i, '`')
+	fence := src[i : i+n]
+
+	// Find a closing run of exactly the same length
+	for j := i + n; j < len(src); {
+		k := strings.Index(src[j:], fence)
+		if k < 0 {
+			break
+		}
+
+		j += k
+		if runLength(src, j, '`') != n {
+			j += runLength(src, j, '`')
+			continue
+		}
+
+		code := strings.ReplaceAll(src[i+n:j], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+
+		ip.add(Code().Text(code))
+		return j + n
+	}
+
+	ip.text.WriteString(fence)
+	return i + n
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// emphasis parses *em*, _em_, **strong**, __strong__ and ~~del~~ by
+// looking for a matching closing delimiter run
+func (ip *inlineParser) emphasis(i int) int {
+	src := ip.src
+	c := src[i]
+	n := runLength(src, i, c)
+
+	var before, after rune = ' ', ' '
+	if i > 0 {
+		before = lastRune(src[:i])
+	}
+	if i+n < len(src) {
+		after = firstRune(src[i+n:])
+	}
+
+	canOpen := !unicode.IsSpace(after)
+	if c == '_' && isWordRune(before) {
+		canOpen = false
+	}
+
+	if c == '~' && n != 2 || !canOpen || n > 3 {
+		ip.text.WriteString(src[i : i+n])
+		return i + n
+	}
+
+	if end := ip.closer(i+n, c, n); end >= 0 {
+		inner := ip.p.inline(src[i+n : end])
+
+		var el Node
+		switch {
+		case c == '~':
+			el = Del().Children(inner...)
+		case n == 1:
+			el = Em().Children(inner...)
+		case n == 2:
+			el = Strong().Children(inner...)
+		default:
+			el = Em().Children(Strong().Children(inner...))
+		}
+
+		ip.add(el)
+		return end + n
+	}
+
+	ip.text.WriteString(src[i : i+n])
+	return i + n
+}
+
+// closer returns the index of a delimiter run of n c's that can close
+// emphasis opened before i, or -1 if there is none
+func (ip *inlineParser) closer(i int, c byte, n int) int {
+	run := delimiterRun{c, n}
+	if failed, ok := ip.noCloser[run]; ok && i >= failed {
+		return -1
+	}
+
+	src := ip.src
+	for j := i; j < len(src); {
+		switch src[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			// Code spans take precedence over emphasis
+			m := runLength(src, j, '`')
+			if k := strings.Index(src[j+m:], src[j:j+m]); k >= 0 {
+				j += m + k + m
+			} else {
+				j += m
+			}
+			continue
+		case c:
+			m := runLength(src, j, c)
+			before := lastRune(src[:j])
+			var after rune = ' '
+			if j+m < len(src) {
+				after = firstRune(src[j+m:])
+			}
+
+			canClose := j > i && !unicode.IsSpace(before)
+			if c == '_' && isWordRune(after) {
+				canClose = false
+			}
+
+			if canClose && m == n {
+				return j
+			}
+
+			j += m
+			continue
+		}
+		j++
+	}
+
+	if ip.noCloser == nil {
+		ip.noCloser = make(map[delimiterRun]int)
+	}
+	ip.noCloser[run] = i
+	return -1
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return ' '
+}
+
+func lastRune(s string) rune {
+	if s == "" {
+		return ' '
+	}
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// link parses inline links, images and reference links starting at the
+// opening bracket at i
+func (ip *inlineParser) link(i int, image bool) int {
+	src := ip.src
+	start := i
+	if image {
+		start--
+	}
+
+	end := matchingBracket(src, i)
+	if end < 0 {
+		ip.text.WriteString(src[start : i+1])
+		return i + 1
+	}
+
+	label := src[i+1 : end]
+	rest := src[end+1:]
+
+	var (
+		target markdownLink
+		ok     bool
+		next   int
+	)
+
+	if strings.HasPrefix(rest, "(") {
+		target, next, ok = parseLinkTarget(rest)
+		next += end + 1
+	}
+
+	if !ok {
+		// Reference links: [text][ref], [text][] and [text]
+		ref, refEnd := label, end+1
+		if strings.HasPrefix(rest, "[") {
+			if close := strings.IndexByte(rest, ']'); close > 0 {
+				if r := rest[1:close]; r != "" {
+					ref = r
+				}
+				refEnd = end + 1 + close + 1
+			}
+		}
+
+		target, ok = ip.p.refs[normalizeLabel(ref)]
+		next = refEnd
+	}
+
+	if !ok {
+		ip.text.WriteString(src[start : i+1])
+		return i + 1
+	}
+
+	children := ip.p.inline(label)
+
+	var el Node
+	if image {
+		var alt strings.Builder
+		rawText(Fragment().Children(children...).GetNode(), &alt)
+		el = Img()
+		if ip.p.allowURL(target.href) {
+			el.Src(target.href)
+		}
+		el.Alt(alt.String())
+	} else {
+		el = A().Children(children...)
+		if ip.p.allowURL(target.href) {
+			el.Href(target.href)
+		}
+	}
+
+	if target.title != "" {
+		el.Title(target.title)
+	}
+
+	ip.add(el)
+	return next
+}
+
+// matchingBracket returns the index of the ] that closes the [ at i
+func matchingBracket(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			m := runLength(s, j, '`')
+			if k := strings.Index(s[j+m:], s[j:j+m]); k >= 0 {
+				j += m + k + m - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// parseLinkTarget parses a link destination and optional title in the form
+// (href "title"), returning the index after the closing parenthesis
+func parseLinkTarget(s string) (markdownLink, int, bool) {
+	var (
+		link markdownLink
+		i    = 1
+	)
+
+	skipSpace := func() {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+			i++
+		}
+	}
+
+	skipSpace()
+	if i < len(s) && s[i] == '<' {
+		end := strings.IndexAny(s[i:], ">\n")
+		if end < 0 || s[i+end] != '>' {
+			return link, 0, false
+		}
+		link.href = s[i+1 : i+end]
+		i += end + 1
+	} else {
+		start, depth := i, 0
+		for ; i < len(s) && s[i] > ' '; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			} else if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		link.href = unescapeMarkdown(s[start:i])
+	}
+
+	skipSpace()
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closing := s[i]
+		if closing == '(' {
+			closing = ')'
+		}
+
+		end := strings.IndexByte(s[i+1:], closing)
+		if end < 0 {
+			return link, 0, false
+		}
+
+		link.title = html.UnescapeString(unescapeMarkdown(s[i+1 : i+1+end]))
+		i += end + 2
+		skipSpace()
+	}
+
+	if i >= len(s) || s[i] != ')' {
+		return link, 0, false
+	}
+
+	link.href = html.UnescapeString(link.href)
+	return link, i + 1, true
+}
+
+// unescapeMarkdown removes backslashes before escaped punctuation
+func unescapeMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+var (
+	mdAutolink   = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*)>`)
+	mdEmailLink  = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*)>`)
+	mdInlineHTML = regexp.MustCompile(`^<(?:/?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?|!--[\s\S]*?--)>`)
+	mdBareURL    = regexp.MustCompile(`^(?:https?://|www\.)[^\s<]*[^\s<?!.,:*_~)'"]`)
+)
+
+// angle parses autolinks and inline HTML starting with < at i
+func (ip *inlineParser) angle(i int) int {
+	src := ip.src[i:]
+
+	if m := mdAutolink.FindStringSubmatch(src); m != nil && ip.p.allowURL(m[1]) {
+		ip.add(A().Href(m[1]).Text(m[1]))
+		return i + len(m[0])
+	}
+
+	if m := mdEmailLink.FindStringSubmatch(src); m != nil {
+		ip.add(A().Href("mailto:" + m[1]).Text(m[1]))
+		return i + len(m[0])
+	}
+
+	if m := mdInlineHTML.FindString(src); m != "" && ip.p.opts.UnsafeHTML {
+		ip.add(UnsafeText(m))
+		return i + len(m)
+	}
+
+	ip.text.WriteByte('<')
+	return i + 1
+}
+
+// autolink parses bare URLs, as in the GFM autolink extension
+func (ip *inlineParser) autolink(i int) int {
+	src := ip.src
+	if i > 0 && isWordRune(lastRune(src[:i])) {
+		ip.text.WriteByte(src[i])
+		return i + 1
+	}
+
+	m := mdBareURL.FindString(src[i:])
+	if m == "" {
+		ip.text.WriteByte(src[i])
+		return i + 1
+	}
+
+	href := m
+	if strings.HasPrefix(m, "www.") {
+		href = "http://" + m
+	}
+
+	if !ip.p.allowURL(href) {
+		ip.text.WriteString(m)
+		return i + len(m)
+	}
+
+	ip.add(A().Href(href).Text(m))
+	return i + len(m)
+}
+
+// safeURLSchemes are the schemes allowed in links and images unless
+// UnsafeHTML is set
+var safeURLSchemes = []string{"http", "https", "mailto"}
+
+// allowURL returns whether a link or image can use the URL. Without
+// UnsafeHTML, only relative URLs and those with a scheme in safeURLSchemes
+// are allowed.
+func (p *markdownParser) allowURL(u string) bool {
+	if p.opts.UnsafeHTML {
+		return true
+	}
+
+	// Browsers ignore whitespace and control characters in the scheme, so
+	// "java\tscript:" is still javascript:
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+
+	colon := strings.IndexByte(u, ':')
+	if colon < 0 || strings.ContainsAny(u[:colon], "/?#") {
+		return true
+	}
+
+	return slices.Contains(safeURLSchemes, strings.ToLower(u[:colon]))
+}
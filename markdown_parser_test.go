@@ -0,0 +1,160 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestFromMarkdown(t *testing.T) {
+	t.Run("parses headings and paragraphs", func(t *testing.T) {
+		root := FromMarkdown(strings.Join([]string{
+			"# Title",
+			"",
+			"Some *em*, **strong**, `code` and ~~del~~.",
+			"Same paragraph.",
+			"",
+			"Setext",
+			"------",
+		}, "\n"), MarkdownOptions{})
+		assert.Equal(t, strings.Join([]string{
+			"<h1>Title</h1>",
+			"<p>Some <em>em</em>, <strong>strong</strong>, <code>code</code> and <del>del</del>.\nSame paragraph.</p>",
+			"<h2>Setext</h2>",
+		}, ""), root.ToHTML())
+	})
+
+	t.Run("parses links and images", func(t *testing.T) {
+		root := FromMarkdown(strings.Join([]string{
+			`[Yaak](https://yaak.app "The app") and ![a *cat*](cat.png)`,
+			"[ref link][docs], <https://example.com> and www.example.com",
+			"",
+			"[docs]: https://docs.example.com",
+		}, "\n"), MarkdownOptions{})
+		assert.Equal(t, strings.Join([]string{
+			`<p><a href="https://yaak.app" title="The app">Yaak</a> and <img src="cat.png" alt="a cat"/>`,
+			"\n",
+			`<a href="https://docs.example.com">ref link</a>, <a href="https://example.com">https://example.com</a>`,
+			` and <a href="http://www.example.com">www.example.com</a></p>`,
+		}, ""), root.ToHTML())
+	})
+
+	t.Run("parses lists", func(t *testing.T) {
+		root := FromMarkdown(strings.Join([]string{
+			"- Fruits",
+			"  1. Apple",
+			"  2. Banana",
+			"- Vegetables",
+			"",
+			"1. Loose",
+			"",
+			"2. List",
+			"",
+			"3) Start",
+		}, "\n"), MarkdownOptions{})
+		assert.Equal(t, strings.Join([]string{
+			"<ul>",
+			"<li>Fruits<ol><li>Apple</li><li>Banana</li></ol></li>",
+			"<li>Vegetables</li>",
+			"</ul>",
+			"<ol><li><p>Loose</p></li><li><p>List</p></li></ol>",
+			`<ol start="3"><li>Start</li></ol>`,
+		}, ""), root.ToHTML())
+	})
+
+	t.Run("parses code, quotes and rules", func(t *testing.T) {
+		root := FromMarkdown(strings.Join([]string{
+			"```go",
+			"if a < b {",
+			"}",
+			"```",
+			"",
+			"> quoted",
+			"lazy",
+			"",
+			"***",
+			"",
+			"    indented",
+		}, "\n"), MarkdownOptions{})
+		assert.Equal(t, strings.Join([]string{
+			`<pre><code class="language-go">if a &lt; b {`,
+			"\n}</code></pre>",
+			"<blockquote><p>quoted\nlazy</p></blockquote>",
+			"<hr/>",
+			"<pre><code>indented</code></pre>",
+		}, ""), root.ToHTML())
+	})
+
+	t.Run("parses tables", func(t *testing.T) {
+		root := FromMarkdown(strings.Join([]string{
+			"| Name | Qty |",
+			"| :--- | --: |",
+			"| `a|b` | 1 |",
+		}, "\n"), MarkdownOptions{})
+		assert.Equal(t, strings.Join([]string{
+			"<table>",
+			`<thead><tr><th style="text-align:left">Name</th><th style="text-align:right">Qty</th></tr></thead>`,
+			`<tbody><tr><td style="text-align:left"><code>a|b</code></td><td style="text-align:right">1</td></tr></tbody>`,
+			"</table>",
+		}, ""), root.ToHTML())
+	})
+
+	t.Run("escapes HTML unless allowed", func(t *testing.T) {
+		src := "<b>bold</b> & \\*stars\\*"
+		assert.Equal(t, "<p>&lt;b&gt;bold&lt;/b&gt; &amp; *stars*</p>", FromMarkdown(src, MarkdownOptions{}).ToHTML())
+		assert.Equal(t, "<p><b>bold</b> &amp; *stars*</p>", FromMarkdown(src, MarkdownOptions{UnsafeHTML: true}).ToHTML())
+	})
+
+	t.Run("unescapes reference definitions like inline links", func(t *testing.T) {
+		inline := FromMarkdown(`[x](/a\_b&amp;c "t\*&amp;")`, MarkdownOptions{}).ToHTML()
+		assert.Equal(t, `<p><a href="/a_b&amp;c" title="t*&amp;">x</a></p>`, inline)
+		assert.Equal(t, inline, FromMarkdown("[x]\n\n[x]: /a\\_b&amp;c \"t\\*&amp;\"", MarkdownOptions{}).ToHTML())
+	})
+
+	t.Run("drops unsafe URLs unless allowed", func(t *testing.T) {
+		md := func(src string) string {
+			return FromMarkdown(src, MarkdownOptions{}).ToHTML()
+		}
+
+		assert.Equal(t, `<p><a>x</a></p>`, md("[x](javascript:alert(1))"))
+		assert.Equal(t, `<p><a>x</a></p>`, md("[x](JavaScript:alert(1))"))
+		assert.Equal(t, `<p><a>x</a></p>`, md("[x](java&#9;script:alert(1))"))
+		assert.Equal(t, `<p><a>x</a></p>`, md("[x][ref]\n\n[ref]: vbscript:msgbox(1)"))
+		assert.Equal(t, `<p><a>x</a></p>`, md("[x][ref]\n\n[ref]: java&#9;script:alert(1)"))
+		assert.Equal(t, `<p><img alt="x"/></p>`, md("![x](data:text/html;base64,PHNjcmlwdD4=)"))
+		assert.Equal(t, `<p>&lt;javascript:alert(1)&gt;</p>`, md("<javascript:alert(1)>"))
+
+		assert.Equal(t, `<p><a href="https://example.com">x</a> <a href="/a:b">y</a> <a href="mailto:a@b.co">z</a></p>`,
+			md("[x](https://example.com) [y](/a:b) [z](mailto:a@b.co)"))
+		assert.Equal(t, `<p><img src="img.png" alt="x"/></p>`, md("![x](img.png)"))
+		assert.Equal(t, `<p><a href="https://example.com">https://example.com</a> <a href="http://www.example.com">www.example.com</a></p>`,
+			md("<https://example.com> www.example.com"))
+
+		unsafe := FromMarkdown("[x](javascript:go()) <data:x>", MarkdownOptions{UnsafeHTML: true}).ToHTML()
+		assert.Equal(t, `<p><a href="javascript:go()">x</a> <a href="data:x">data:x</a></p>`, unsafe)
+	})
+
+	t.Run("parses unmatched delimiters in linear time", func(t *testing.T) {
+		for _, run := range []string{"*a ", "_a ", "**a ", "~~a "} {
+			src := strings.TrimSpace(strings.Repeat(run, 20000))
+
+			start := time.Now()
+			root := FromMarkdown(src, MarkdownOptions{})
+			assert.Less(t, time.Since(start), 2*time.Second, "parsing %q", run)
+			assert.Equal(t, "<p>"+src+"</p>", root.ToHTML())
+		}
+	})
+
+	t.Run("generates heading IDs", func(t *testing.T) {
+		root := FromMarkdown("# Hello, World!\n\n## Hello, World!", MarkdownOptions{HeadingIDs: true})
+		assert.Equal(t, `<h1 id="hello-world">Hello, World!</h1><h2 id="hello-world-1">Hello, World!</h2>`, root.ToHTML())
+	})
+
+	t.Run("builds nodes that can be extended", func(t *testing.T) {
+		root := Article().Class("prose").Children(FromMarkdown("Hello", MarkdownOptions{}))
+		assert.Equal(t, `<article class="prose"><p>Hello</p></article>`, root.ToHTML())
+	})
+}
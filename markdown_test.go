@@ -0,0 +1,107 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestElement_ToMarkdown(t *testing.T) {
+	t.Run("renders headings and inline elements", func(t *testing.T) {
+		root := Div().Children(
+			H1().Text("Title"),
+			P().Children(
+				Text("Some "),
+				Strong().Text("bold "),
+				Em().Text("italic"),
+				Text(", "),
+				Code().Text("code"),
+				Text(" and a "),
+				A().Href("https://yaak.app").Title("Yaak").Text("link"),
+				Text("."),
+			),
+			P().Children(Img().Src("cat.png").Alt("A cat")),
+		)
+		assert.Equal(t, strings.Join([]string{
+			"# Title",
+			"",
+			`Some **bold** *italic*, ` + "`code`" + ` and a [link](https://yaak.app "Yaak").`,
+			"",
+			"![A cat](cat.png)",
+		}, "\n"), root.ToMarkdown())
+	})
+
+	t.Run("escapes text", func(t *testing.T) {
+		root := Div().Children(
+			P().Text("# not a heading"),
+			P().Text("1. not a list *or* [link]"),
+		)
+		assert.Equal(t, `\# not a heading`+"\n\n"+`1\. not a list \*or\* \[link\]`, root.ToMarkdown())
+	})
+
+	t.Run("renders nested lists", func(t *testing.T) {
+		root := Ul().Children(
+			Li().Children(
+				Text("Fruits"),
+				Ol().Children(Li().Text("Apple"), Li().Text("Banana")),
+			),
+			Li().Text("Vegetables"),
+		)
+		assert.Equal(t, strings.Join([]string{
+			"- Fruits",
+			"  1. Apple",
+			"  2. Banana",
+			"- Vegetables",
+		}, "\n"), root.ToMarkdown())
+	})
+
+	t.Run("renders code blocks and blockquotes", func(t *testing.T) {
+		root := Div().Children(
+			Pre().Children(Code().Class("language-go").Text("func main() {\n\tprintln(\"```\")\n}")),
+			Blockquote().Children(P().Text("Quote"), P().Text("More")),
+			Hr(),
+		)
+		assert.Equal(t, strings.Join([]string{
+			"````go",
+			"func main() {",
+			"\tprintln(\"```\")",
+			"}",
+			"````",
+			"",
+			"> Quote",
+			">",
+			"> More",
+			"",
+			"---",
+		}, "\n"), root.ToMarkdown())
+	})
+
+	t.Run("renders tables", func(t *testing.T) {
+		root := Table().Children(
+			Thead().Children(Tr().Children(Th().Text("Name"), Th().Text("Note"))),
+			Tbody().Children(
+				Tr().Children(Td().Text("Alice"), Td().Children(Text("a|b"), Br(), Text("c"))),
+			),
+		)
+		assert.Equal(t, strings.Join([]string{
+			"| Name  | Note      |",
+			"| ----- | --------- |",
+			`| Alice | a\|b<br>c |`,
+		}, "\n"), root.ToMarkdown())
+	})
+
+	t.Run("falls back to HTML", func(t *testing.T) {
+		root := P().Children(Text("H"), Sub().Text("2"), Text("O"))
+		assert.Equal(t, "H<sub>2</sub>O", root.ToMarkdown())
+
+		raw := P().Children(Text("Some "), UnsafeText("<b>raw</b> &amp; *html*"))
+		assert.Equal(t, "Some <b>raw</b> &amp; *html*", raw.ToMarkdown())
+	})
+
+	t.Run("escapes titles", func(t *testing.T) {
+		root := P().Children(A().Href("/").Title(`Café "quoted" \ slash`).Text("link"))
+		assert.Equal(t, `[link](/ "Café \"quoted\" \\ slash")`, root.ToMarkdown())
+	})
+}
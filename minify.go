@@ -0,0 +1,298 @@
+package hagl
+
+import (
+	"html"
+	"slices"
+	"strings"
+)
+
+// MinifyOptions configures ToHTMLMinified. The zero value applies every
+// optimization, and each option turns one of them off.
+type MinifyOptions struct {
+	// KeepComments keeps all comments. Otherwise, only conditional comments
+	// like <!--[if IE]> are kept.
+	KeepComments bool
+
+	// KeepWhitespace keeps whitespace in text as-is. Otherwise, runs of
+	// whitespace outside of preformatted elements are collapsed.
+	KeepWhitespace bool
+
+	// KeepQuotes always quotes attribute values. Otherwise, quotes are
+	// omitted when the value doesn't need them.
+	KeepQuotes bool
+
+	// KeepClosingTags always renders closing tags. Otherwise, closing tags
+	// that HTML allows to be omitted, like </li> and </p>, are left out.
+	KeepClosingTags bool
+
+	// KeepDefaultAttributes keeps attributes that are set to their default
+	// value, like type="text" on inputs, and empty class and style attributes
+	KeepDefaultAttributes bool
+}
+
+// voidEls are elements that never have content, so don't need to be closed
+var voidEls = []string{
+	"area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta",
+	"param", "source", "track", "wbr",
+}
+
+// booleanAttrs are attributes whose value doesn't matter, only whether
+// they are present
+var booleanAttrs = []string{
+	"allowfullscreen", "async", "autofocus", "autoplay", "checked", "controls",
+	"default", "defer", "disabled", "formnovalidate", "hidden", "inert", "ismap",
+	"itemscope", "loop", "multiple", "muted", "nomodule", "novalidate", "open",
+	"playsinline", "readonly", "required", "reversed", "selected",
+}
+
+// defaultAttrs maps tag names to attributes that can be removed when they
+// have the given value
+var defaultAttrs = map[string]map[string]string{
+	"area":     {"shape": "rect"},
+	"button":   {"type": "submit"},
+	"form":     {"method": "get", "autocomplete": "on", "enctype": "application/x-www-form-urlencoded"},
+	"input":    {"type": "text"},
+	"link":     {"type": "text/css"},
+	"ol":       {"type": "1"},
+	"script":   {"type": "text/javascript", "language": "javascript"},
+	"style":    {"type": "text/css", "media": "all"},
+	"td":       {"colspan": "1", "rowspan": "1"},
+	"textarea": {"wrap": "soft"},
+	"th":       {"colspan": "1", "rowspan": "1"},
+}
+
+// paragraphClosers are elements that close an open <p> when they follow it
+var paragraphClosers = []string{
+	"address", "article", "aside", "blockquote", "details", "dialog", "div",
+	"dl", "fieldset", "figcaption", "figure", "footer", "form", "h1", "h2",
+	"h3", "h4", "h5", "h6", "header", "hgroup", "hr", "main", "menu", "nav",
+	"ol", "p", "pre", "search", "section", "table", "ul",
+}
+
+type minifier struct {
+	opts MinifyOptions
+	b    strings.Builder
+
+	// space is whether the last text written ended in collapsed whitespace
+	space bool
+}
+
+func (m *minifier) render(rn *RawNode) string {
+	m.node(rn, nil, nil, false)
+	return m.b.String()
+}
+
+// visible returns the children that will be rendered, with the children of
+// fragments in place of the fragments themselves
+func (m *minifier) visible(nodes []Node) []*RawNode {
+	var out []*RawNode
+	for _, c := range nodes {
+		n := c.GetNode()
+		switch {
+		case n.hide, n.nodeType == headNode:
+		case n.nodeType == fragmentNode:
+			out = append(out, m.visible(n.children)...)
+		case n.nodeType == commentNode && !m.keepComment(n):
+		default:
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (m *minifier) keepComment(n *RawNode) bool {
+	_, conditional := conditionalComment(n)
+	return m.opts.KeepComments || conditional
+}
+
+// conditionalComment returns the text of a conditional comment, like
+// "[if mso]><table><![endif]", and whether n is one. It's written without
+// escaping or padding, or IE and Outlook don't recognize it.
+func conditionalComment(n *RawNode) (string, bool) {
+	var b strings.Builder
+	rawText(n, &b)
+	text := strings.TrimSpace(b.String())
+	return text, strings.HasPrefix(text, "[if ") || strings.HasPrefix(text, "<![endif]")
+}
+
+// node renders n, which is followed by next inside parent. Either may be
+// nil. Inside preformatted elements, whitespace is kept as-is.
+func (m *minifier) node(n, parent, next *RawNode, pre bool) {
+	switch n.nodeType {
+	case textNode:
+		m.text(n, pre)
+		return
+	case commentNode:
+		if text, ok := conditionalComment(n); ok {
+			m.b.WriteString("<!--" + text + "-->")
+		} else {
+			m.b.WriteString(n.toHTML(-1, nil))
+		}
+		return
+	case fragmentNode:
+		m.children(n, pre)
+		return
+	case doctypeNode:
+		m.b.WriteString("<!DOCTYPE html>")
+		return
+	}
+
+	pre = pre || n.preformatted || n.tag == "style"
+	children := m.visible(n.children)
+
+	m.b.WriteString("<" + n.tag)
+	for _, a := range n.attrs {
+		m.attr(n.tag, a)
+	}
+
+	if len(children) == 0 && n.selfClosing {
+		if slices.Contains(voidEls, n.tag) && !m.opts.KeepClosingTags {
+			m.b.WriteString(">")
+		} else {
+			m.b.WriteString("/>")
+		}
+
+		// Whitespace after an element like <img> is significant, so it can't
+		// be collapsed into whitespace before it
+		m.space = false
+		return
+	}
+
+	m.b.WriteString(">")
+	m.children(n, pre)
+
+	if m.opts.KeepClosingTags || !canOmitClosingTag(n, parent, next) {
+		m.b.WriteString("</" + n.tag + ">")
+		m.space = false
+	}
+}
+
+func (m *minifier) children(n *RawNode, pre bool) {
+	children := m.visible(n.children)
+	for i, c := range children {
+		var next *RawNode
+		if i+1 < len(children) {
+			next = children[i+1]
+		}
+		m.node(c, n, next, pre)
+	}
+}
+
+func (m *minifier) text(n *RawNode, pre bool) {
+	// Raw HTML from UnsafeText may contain preformatted elements of its own,
+	// so it's kept as-is
+	if pre || m.opts.KeepWhitespace || n.unsafe {
+		m.b.WriteString(n.text)
+		m.space = false
+		return
+	}
+
+	for _, c := range n.text {
+		if isHTMLSpace(c) {
+			if !m.space {
+				m.b.WriteByte(' ')
+			}
+			m.space = true
+			continue
+		}
+
+		m.b.WriteRune(c)
+		m.space = false
+	}
+}
+
+func (m *minifier) attr(tag string, a attr) {
+	name := sanitizeAttrName(a.name)
+
+	if !m.opts.KeepDefaultAttributes {
+		if (name == "class" || name == "style") && strings.TrimSpace(a.value) == "" {
+			return
+		}
+
+		if def, ok := defaultAttrs[tag][name]; ok && strings.EqualFold(a.value, def) {
+			return
+		}
+	}
+
+	m.b.WriteString(" " + name)
+
+	// Boolean attributes only need their name
+	if slices.Contains(booleanAttrs, name) && (a.value == "" || strings.EqualFold(a.value, name)) {
+		return
+	}
+
+	value := html.EscapeString(a.value)
+	if m.opts.KeepQuotes || !canOmitQuotes(value) {
+		value = "\"" + value + "\""
+	}
+
+	m.b.WriteString("=" + value)
+}
+
+// canOmitQuotes returns whether an escaped attribute value is valid
+// without quotes
+func canOmitQuotes(value string) bool {
+	return value != "" && !strings.ContainsAny(value, " \t\n\f\r\"'=<>`") &&
+		!strings.HasSuffix(value, "/")
+}
+
+// canOmitClosingTag returns whether the closing tag of n can be left out,
+// following the optional tag rules of the HTML specification
+func canOmitClosingTag(n, parent, next *RawNode) bool {
+	// Any text after the element must stay outside of it
+	if next != nil && next.nodeType != tagNode {
+		return false
+	}
+
+	// Without a parent element, there's no telling what will follow
+	if next == nil && (parent == nil || parent.nodeType != tagNode) {
+		return false
+	}
+
+	nextTag := ""
+	if next != nil {
+		nextTag = next.tag
+	}
+
+	switch n.tag {
+	case "html", "body":
+		return next == nil
+	case "head":
+		return next == nil || nextTag == "body"
+	case "li":
+		return next == nil || nextTag == "li"
+	case "dt":
+		return nextTag == "dt" || nextTag == "dd"
+	case "dd":
+		return next == nil || nextTag == "dt" || nextTag == "dd"
+	case "rt", "rp":
+		return next == nil || nextTag == "rt" || nextTag == "rp"
+	case "optgroup":
+		return next == nil || nextTag == "optgroup"
+	case "option":
+		return next == nil || nextTag == "option" || nextTag == "optgroup"
+	case "colgroup":
+		return next == nil || nextTag != "colgroup"
+	case "thead":
+		return nextTag == "tbody" || nextTag == "tfoot"
+	case "tbody":
+		return next == nil || nextTag == "tbody" || nextTag == "tfoot"
+	case "tfoot":
+		return next == nil
+	case "tr":
+		return next == nil || nextTag == "tr"
+	case "td", "th":
+		return next == nil || nextTag == "td" || nextTag == "th"
+	case "p":
+		if next == nil {
+			// Autonomous custom elements, which have a "-" in their name,
+			// don't close paragraphs either
+			return !strings.Contains(parent.tag, "-") && !slices.Contains(
+				[]string{"a", "audio", "del", "ins", "map", "noscript", "video"}, parent.tag,
+			)
+		}
+		return slices.Contains(paragraphClosers, nextTag)
+	}
+
+	return false
+}
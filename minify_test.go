@@ -0,0 +1,93 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestElement_ToHTMLMinified(t *testing.T) {
+	t.Run("strips comments and collapses whitespace", func(t *testing.T) {
+		root := Div().Children(
+			Comment("Not needed"),
+			Text("  Hello \n\n "),
+			Strong().Text(" World "),
+			Pre().Text("  keep\n  this"),
+		)
+		assert.Equal(t, "<div> Hello <strong>World </strong><pre>  keep\n  this</pre></div>", root.ToHTMLMinified(MinifyOptions{}))
+	})
+
+	t.Run("keeps whitespace around void and closed elements", func(t *testing.T) {
+		root := P().Children(Text("a "), Img().Src("x.png"), Text(" b "), Span().Text("c "), Text(" d"))
+		assert.Equal(t, "<p>a <img src=x.png> b <span>c </span> d</p>", root.ToHTMLMinified(MinifyOptions{}))
+	})
+
+	t.Run("keeps conditional comments as-is", func(t *testing.T) {
+		root := Div().Children(
+			Comment("[if mso]><table><tr><td><![endif]"),
+			Comment("Not needed"),
+			Text("Hi"),
+			Comment("[if mso]></td></tr></table><![endif]"),
+		)
+		assert.Equal(t, "<div><!--[if mso]><table><tr><td><![endif]-->Hi<!--[if mso]></td></tr></table><![endif]--></div>",
+			root.ToHTMLMinified(MinifyOptions{}))
+	})
+
+	t.Run("keeps unsafe text as-is", func(t *testing.T) {
+		root := P().Children(Text(" a  b "), UnsafeText("c  &amp;\n d"), Text(" e"))
+		assert.Equal(t, "<p> a b c  &amp;\n d e</p>", root.ToHTMLMinified(MinifyOptions{}))
+	})
+
+	t.Run("omits quotes, defaults and empty attributes", func(t *testing.T) {
+		root := Form().Method("get").Children(
+			Input().Type("text").Name("q").Class().Attr("placeholder", "Search here").AttrBool("required"),
+		)
+		assert.Equal(t, `<form><input name=q placeholder="Search here" required></form>`, root.ToHTMLMinified(MinifyOptions{}))
+	})
+
+	t.Run("omits optional closing tags", func(t *testing.T) {
+		root := Div().Children(
+			Ul().Children(Li().Text("One"), Li().Text("Two")),
+			P().Text("Para"),
+			Table().Children(
+				Tbody().Children(
+					Tr().Children(Td().Text("A"), Td().Text("B")),
+					Tr().Children(Td().Text("C")),
+				),
+			),
+			P().Text("Last"),
+		)
+		assert.Equal(t,
+			"<div><ul><li>One<li>Two</ul><p>Para<table><tbody><tr><td>A<td>B<tr><td>C</table><p>Last</div>",
+			root.ToHTMLMinified(MinifyOptions{}),
+		)
+	})
+
+	t.Run("keeps closing tags when needed", func(t *testing.T) {
+		root := Div().Children(
+			P().Text("Followed by text"),
+			Text("text"),
+			A().Children(P().Text("In a link")),
+		)
+		assert.Equal(t, "<div><p>Followed by text</p>text<a><p>In a link</p></a></div>", root.ToHTMLMinified(MinifyOptions{}))
+		assert.Equal(t, "<li>Root</li>", Li().Text("Root").ToHTMLMinified(MinifyOptions{}))
+		assert.Equal(t, "<x-card><p>In a custom element</p></x-card>",
+			NewElement("x-card").Children(P().Text("In a custom element")).ToHTMLMinified(MinifyOptions{}))
+	})
+
+	t.Run("can disable optimizations", func(t *testing.T) {
+		root := Ul().Class("").Children(
+			Comment("comment"),
+			Li().Attr("data-x", "1").Text("a  b"),
+		)
+		opts := MinifyOptions{
+			KeepComments:          true,
+			KeepWhitespace:        true,
+			KeepQuotes:            true,
+			KeepClosingTags:       true,
+			KeepDefaultAttributes: true,
+		}
+		assert.Equal(t, root.ToHTML(), root.ToHTMLMinified(opts))
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"html"
 	"io"
-	"regexp"
 	"slices"
 	"strings"
 )
@@ -12,8 +11,6 @@ import (
 func newEl() *RawNode {
 	return &RawNode{
 		nodeType:        tagNode,
-		styles:          make(map[string]string),
-		tab:             "  ",
 		indentIncrement: 1,
 	}
 }
@@ -48,6 +45,8 @@ const (
 	tagNode
 	commentNode
 	fragmentNode
+	doctypeNode
+	headNode
 )
 
 type Node interface {
@@ -64,10 +63,16 @@ type Node interface {
 	ClassIf(condition bool, cls string) Node
 	StyleProperty(name, value string) Node
 	Style(value string) Node
+	Styles(s *Styles) Node
 	Value(value string) Node
 	ToHTML() string
 	ToHTMLPretty() string
+	ToHTMLPrettyWith(opts PrettyOptions) string
+	ToHTMLMinified(opts MinifyOptions) string
 	ToText() string
+	ToTextWith(opts TextOptions) string
+	ToMarkdown() string
+	ToANSI(opts ANSIOptions) string
 	Write(w io.Writer) (int, error)
 	WritePretty(w io.Writer) (int, error)
 	MustWrite(w io.Writer)
@@ -88,6 +93,22 @@ type Node interface {
 	Type(value string) Node
 	Title(value string) Node
 
+	// htmx helpers
+
+	HxGet(url string) Node
+	HxPost(url string) Node
+	HxTarget(selector string) Node
+	HxSwap(swap Swap) Node
+	HxTrigger(trigger string) Node
+	HxSwapOOB(swap Swap) Node
+
+	// Typed attribute helpers
+
+	Data(key, value string) Node
+	Aria(key ARIA, value string) Node
+	Role(role Role) Node
+	On(event Event, script string) Node
+
 	// GetNode returns the root node. This is for internal use only
 	GetNode() *RawNode
 }
@@ -99,16 +120,16 @@ type RawNode struct {
 	// attrs contains the attributes for the element
 	attrs []attr
 
-	// styles hold the styles assigned to the element, which will
-	// be converted to attributes when rendered
-	styles map[string]string
-
 	// tag defines the name of the HTML element
 	tag string
 
 	// text contains the text contents of the element
 	text string
 
+	// unsafe is set for text from UnsafeText, which is HTML rather than
+	// escaped text
+	unsafe bool
+
 	// nodeType defines the type of node
 	nodeType nodeType
 
@@ -125,8 +146,6 @@ type RawNode struct {
 	// This is most useful for Fragment, which has no indent.
 	indentIncrement int
 
-	// tab specifies the character used to indent
-	tab  string
 	hide bool
 }
 
@@ -189,11 +208,6 @@ func (rn *RawNode) Children(child ...Node) Node {
 			continue
 		}
 
-		// Preformatted nodes have preformatted children
-		if rn.preformatted {
-			c.GetNode().preformatted = true
-		}
-
 		rn.children = append(rn.children, c)
 	}
 	return rn
@@ -219,6 +233,8 @@ func (rn *RawNode) Extend(node Node) Node {
 			if existingAttr.name == attr.name {
 				if existingAttr.name == "class" { // Append to class
 					rn.attrs[i].value = existingAttr.value + " " + attr.value
+				} else if existingAttr.name == "style" { // Merge properties
+					rn.attrs[i].value = parseStyles(existingAttr.value).Merge(parseStyles(attr.value)).String()
 				} else { // Overwrite all others
 					rn.attrs[i].value = attr.value
 				}
@@ -233,9 +249,15 @@ func (rn *RawNode) Extend(node Node) Node {
 		}
 	}
 
-	rn.text = ""
-	rn.Text(n.text)
-	rn.Text(rn.text)
+	// Only text nodes have text, which is already escaped, so add it as-is
+	// rather than escaping it again
+	if n.text != "" {
+		t := newEl()
+		t.nodeType = textNode
+		t.text = n.text
+		t.unsafe = n.unsafe
+		rn.Children(t)
+	}
 
 	return rn
 }
@@ -323,32 +345,64 @@ func (rn *RawNode) Style(value string) Node {
 	return rn
 }
 
-// StyleProperty is a utility method to append to the style attribute. If a style
-// attribute already exists, the new style will be appended.
+// StyleProperty is a utility method to set a property in the style attribute.
+// If the property is already set, its value is replaced.
 func (rn *RawNode) StyleProperty(name, value string) Node {
-	str := name + ":" + value
-	for i, a := range rn.attrs {
-		if a.name == "style" {
-			rn.attrs[i].value += ";" + str
-			return rn
-		}
-	}
+	return rn.Styles(CSS().Set(name, value))
+}
 
-	// No style attr, so add one
-	rn.Attr("style", str)
-	return rn
+// Styles merges the properties into the style attribute, replacing the values
+// of properties that are already set
+func (rn *RawNode) Styles(s *Styles) Node {
+	merged := parseStyles(rn.attr("style")).Merge(s)
+	if len(merged.decls) == 0 {
+		return rn
+	}
+	return rn.Attr("style", merged.String())
 }
 
+// ToText renders the node as plain text using DefaultTextOptions
 func (rn *RawNode) ToText() string {
-	return rn.toText(-1)
+	return rn.ToTextWith(DefaultTextOptions())
+}
+
+// ToTextWith renders the node as plain text using the given options
+func (rn *RawNode) ToTextWith(opts TextOptions) string {
+	return newTextRenderer(opts).render(rn)
+}
+
+// ToHTMLMinified renders the smallest HTML that displays the same as ToHTML
+func (rn *RawNode) ToHTMLMinified(opts MinifyOptions) string {
+	return (&minifier{opts: opts}).render(rn)
 }
 
 func (rn *RawNode) ToHTML() string {
-	return rn.toHTML(-1, false)
+	return rn.toHTML(-1, nil)
 }
 
+// ToHTMLPretty renders indented HTML using DefaultPrettyOptions
 func (rn *RawNode) ToHTMLPretty() string {
-	return strings.TrimSpace(rn.toHTML(0, true))
+	return rn.ToHTMLPrettyWith(DefaultPrettyOptions())
+}
+
+// ToHTMLPrettyWith renders indented HTML using the given options
+func (rn *RawNode) ToHTMLPrettyWith(opts PrettyOptions) string {
+	return strings.TrimSpace(rn.toHTML(0, &opts))
+}
+
+// ToMarkdown renders the node as CommonMark, using GFM extensions for
+// tables and strikethrough. Elements without a Markdown equivalent are
+// rendered as inline HTML.
+func (rn *RawNode) ToMarkdown() string {
+	return new(markdownRenderer).render(rn)
+}
+
+// ToANSI renders the node as text for display in a terminal, using escape
+// sequences for styles and hyperlinks
+func (rn *RawNode) ToANSI(opts ANSIOptions) string {
+	r := newTextRenderer(opts.TextOptions)
+	r.ansi = !opts.NoColor
+	return r.render(rn)
 }
 
 func (rn *RawNode) Write(w io.Writer) (int, error) {
@@ -373,100 +427,41 @@ func (rn *RawNode) MustWritePretty(w io.Writer) {
 	}
 }
 
-func (rn *RawNode) indent(level int, text string) string {
-	prefix := strings.Builder{}
-	for i := 0; i < level; i++ {
-		prefix.WriteString(rn.tab)
-	}
-
-	prefix.WriteString(text)
-	return prefix.String()
-}
-
-func (rn *RawNode) toText(level int) string {
-	// Nothing to do for hidden nodes
-	if rn.hide {
+// toHTML renders the node as HTML, indented according to pretty. A nil
+// pretty renders everything without any added whitespace.
+func (rn *RawNode) toHTML(level int, pretty *PrettyOptions) string {
+	// Nothing to do for hidden nodes, or head entries, which are rendered
+	// into the <head> of the Document instead
+	if rn.hide || rn.nodeType == headNode {
 		return ""
 	}
 
-	if rn.nodeType == textNode {
-		return WrapText(rn.text, 80)
-	}
-
-	innerText := ""
-
-	if rn.isBlock() && rn.tag != "li" {
-		innerText = "\n"
-	}
-
-	// Render children if the element has them
-	for i, c := range rn.children {
-		if c.GetNode().tag == "li" {
-			if rn.tag == "ol" {
-				innerText += fmt.Sprintf(" %d) ", i+1)
-			} else if rn.tag == "ul" {
-				innerText += fmt.Sprintf(" - ")
-			}
-		}
-
-		if rn.preformatted {
-			innerText += c.GetNode().toText(0)
-		} else {
-			innerText += c.GetNode().toText(level + rn.indentIncrement)
-		}
-	}
-
-	if rn.tag == "a" {
-		innerText += fmt.Sprintf(" (%s)", rn.attr("href"))
-	}
-
-	if rn.isBlock() {
-		innerText += "\n"
-	}
-
-	// Trim space if it's the root element, and remove double empty lines
-	if level == -1 {
-		innerText = strings.TrimSpace(innerText)
-		innerText = regexp.MustCompile(`\n{3,}`).ReplaceAllString(innerText, "\n\n")
-	}
-
-	return innerText
-}
-
-func (rn *RawNode) toHTML(level int, prettify bool) string {
-	// Nothing to do for hidden nodes
-	if rn.hide {
-		return ""
+	// Adding whitespace inside inline elements would change how they render
+	if pretty != nil && rn.isInline() {
+		pretty = pretty.inlined()
 	}
 
 	innerHTML := ""
-	onlyTextChildren := true
-
-	// Render children if the element has them
-	for _, c := range rn.children {
-		if c.GetNode().nodeType != textNode {
-			onlyTextChildren = false
-		}
+	oneLine := true
 
-		if rn.preformatted {
-			// Indent open tag but nothing else
-			// TODO: Figure out what to do with tags inside <pre>
-			innerHTML += rn.indent(0, c.GetNode().toHTML(0, false))
-		} else {
-			innerHTML += c.GetNode().toHTML(level+rn.indentIncrement, prettify)
-		}
-
-		// Add newline after each child if we're prettifying. Note, we don't
-		// add one to fragment children because they don't take up space
-		if prettify && !rn.preformatted && c.GetNode().nodeType != fragmentNode {
-			innerHTML += "\n"
+	if pretty != nil && !pretty.inline && !rn.preformatted && rn.nodeType != commentNode {
+		innerHTML, oneLine = rn.prettyChildren(level+rn.indentIncrement, pretty)
+	} else {
+		for _, c := range rn.children {
+			if rn.preformatted {
+				// Whitespace is significant at any depth inside preformatted
+				// elements, so render the children exactly as they are
+				innerHTML += c.GetNode().toHTML(0, nil)
+			} else {
+				innerHTML += c.GetNode().toHTML(level+rn.indentIncrement, pretty)
+			}
 		}
 	}
 
 	var (
-		attrsStr = rn.attrsToString()
-		prefix   string
-		suffix   string
+		attrs  = rn.attrStrings()
+		prefix string
+		suffix string
 	)
 
 	if rn.nodeType == textNode {
@@ -477,43 +472,138 @@ func (rn *RawNode) toHTML(level int, prettify bool) string {
 	} else if rn.nodeType == commentNode {
 		prefix = "<!-- "
 		suffix = " -->"
+	} else if rn.nodeType == doctypeNode {
+		prefix = "<!DOCTYPE html>"
 	} else if rn.selfClosing && innerHTML == "" {
-		prefix = "<" + rn.tag + attrsStr
-		suffix = "/>"
+		prefix = pretty.openTag(level, rn.tag, attrs, "/>")
 	} else {
-		prefix = "<" + rn.tag + attrsStr + ">"
+		prefix = pretty.openTag(level, rn.tag, attrs, ">")
 		suffix = "</" + rn.tag + ">"
 	}
 
 	// Adjust prefix and suffix, depending on what we need
 
-	if !prettify {
-		// we're not prettifying, so leave as-is
+	if pretty == nil || pretty.inline {
+		// we're not adding whitespace, so leave as-is
 	} else if prefix == "" && suffix == "" {
 		// Not wrapping, so leave as is
-	} else if rn.preformatted || onlyTextChildren {
+	} else if rn.preformatted {
+		// Indent the open tag but leave the content untouched
+		prefix = pretty.indent(level, prefix)
+	} else if oneLine {
 		// Put the entire element on one line
-		prefix = rn.indent(level, prefix)
+		prefix = pretty.indent(level, prefix)
 		innerHTML = strings.TrimSpace(innerHTML)
 	} else {
 		// Indent, with start, content, end on separate lines
-		prefix = rn.indent(level, prefix) + "\n"
-		suffix = rn.indent(level, suffix)
+		prefix = pretty.indent(level, prefix) + "\n"
+		innerHTML += "\n"
+		suffix = pretty.indent(level, suffix)
 	}
 
 	return prefix + innerHTML + suffix
 }
 
-func (rn *RawNode) attrsToString() string {
-	items := strings.Builder{}
-	for _, a := range rn.attrs {
-		items.WriteString(" ")
-		items.WriteString(sanitizeAttrName(a.name))
-		items.WriteString("=\"")
-		items.WriteString(html.EscapeString(a.value))
-		items.WriteString("\"")
+// prettyChildren renders the children of a block-level node, putting each
+// block child on its own line. Consecutive inline children are kept together
+// on one line, since whitespace between them would change how they render.
+// If all children fit on one line with the parent, oneLine is true.
+func (rn *RawNode) prettyChildren(level int, pretty *PrettyOptions) (html string, oneLine bool) {
+	var (
+		children []*RawNode
+		flatten  func(nodes []Node)
+	)
+
+	// Fragments don't take up space, so treat their children as siblings
+	flatten = func(nodes []Node) {
+		for _, c := range nodes {
+			n := c.GetNode()
+			if n.hide || n.nodeType == headNode {
+				continue
+			} else if n.nodeType == fragmentNode {
+				flatten(n.children)
+			} else {
+				children = append(children, n)
+			}
+		}
+	}
+	flatten(rn.children)
+
+	var (
+		lines []string
+		run   []*RawNode
+	)
+
+	flush := func() {
+		hasInline := false
+		for _, n := range run {
+			hasInline = hasInline || n.nodeType != commentNode
+		}
+
+		if hasInline {
+			line := ""
+			for _, n := range run {
+				line += n.toHTML(level, pretty.inlined())
+			}
+			lines = append(lines, pretty.indent(level, line))
+		} else {
+			// Comments between blocks get their own lines
+			for _, n := range run {
+				lines = append(lines, n.toHTML(level, pretty))
+			}
+		}
+
+		run = nil
 	}
-	return items.String()
+
+	for _, n := range children {
+		if (n.nodeType == tagNode && !n.isInline()) || n.nodeType == doctypeNode {
+			flush()
+			lines = append(lines, n.toHTML(level, pretty))
+		} else {
+			run = append(run, n)
+		}
+	}
+
+	// Children that are a single run of inline content including some text
+	// stay on the same line as the parent, like <p>Hello <b>World</b></p>
+	if len(lines) == 0 {
+		hasText := len(run) == 0
+		for _, n := range run {
+			hasText = hasText || n.nodeType == textNode
+		}
+
+		if hasText {
+			for _, n := range run {
+				html += n.toHTML(level, pretty.inlined())
+			}
+			return html, true
+		}
+	}
+
+	flush()
+
+	return strings.Join(lines, "\n"), false
+}
+
+// clone returns a deep copy of the node, with any components rendered
+func (rn *RawNode) clone() *RawNode {
+	c := *rn
+	c.attrs = append([]attr(nil), rn.attrs...)
+	c.children = make([]Node, len(rn.children))
+	for i, child := range rn.children {
+		c.children[i] = child.GetNode().clone()
+	}
+	return &c
+}
+
+// attrStrings returns each attribute formatted as name="value"
+func (rn *RawNode) attrStrings() []string {
+	items := make([]string, len(rn.attrs))
+	for i, a := range rn.attrs {
+		items[i] = sanitizeAttrName(a.name) + "=\"" + html.EscapeString(a.value) + "\""
+	}
+	return items
 }
 
 func (rn *RawNode) attr(name string) string {
@@ -525,6 +615,36 @@ func (rn *RawNode) attr(name string) string {
 	return ""
 }
 
+func (rn *RawNode) hasAttr(name string) bool {
+	for _, a := range rn.attrs {
+		if a.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isInline returns whether the element is rendered inline, meaning
+// whitespace around it and inside it is significant. Like in browsers,
+// custom and unknown elements are inline, so it's every element that isn't
+// a block or one of the other elements that aren't laid out inline.
+func (rn *RawNode) isInline() bool {
+	var notInlineEls = []string{
+		// Document structure and metadata
+		"html", "head", "body", "base", "link", "meta", "style", "title",
+		"script", "noscript", "template",
+
+		// Parts of lists, tables, forms and media elements
+		"dd", "dt", "caption", "col", "colgroup", "tbody", "thead", "tfoot",
+		"tr", "td", "th", "legend", "datalist", "optgroup", "option",
+		"area", "param", "source", "track",
+
+		// Sectioning and interactive blocks
+		"details", "dialog", "figcaption", "hgroup", "menu", "search", "summary",
+	}
+	return rn.nodeType == tagNode && !rn.isBlock() && !slices.Contains(notInlineEls, rn.tag)
+}
+
 func (rn *RawNode) isBlock() bool {
 	var blockEls = []string{
 		"address",
@@ -2,11 +2,12 @@ package hagl_test
 
 import (
 	"fmt"
-	assert "github.com/stretchr/testify/require"
-	"strings"
 	"testing"
 
+	assert "github.com/stretchr/testify/require"
+
 	. "github.com/gschier/hagl"
+	"github.com/gschier/hagl/hagltest"
 )
 
 func TestElement_ToHTML(t *testing.T) {
@@ -34,16 +35,7 @@ func TestElement_ToHTML(t *testing.T) {
 			),
 			Button().Class("btn").Text("Click Me!"),
 		)
-		assert.Equal(t, strings.Join([]string{
-			"<div>",
-			"  <h1>Hello World!</h1>",
-			"  <ul>",
-			"    <li>Item 1</li>",
-			"    <li>Item 2</li>",
-			"  </ul>",
-			"  <button class=\"btn\">Click Me!</button>",
-			"</div>",
-		}, "\n"), root.ToHTMLPretty())
+		hagltest.Snapshot(t, "to_html_nested", root)
 	})
 }
 
@@ -73,20 +65,7 @@ func TestElement_ToText(t *testing.T) {
 				A().Href("https://yaak.app").Class("btn").Text("Click Me!"),
 			),
 		)
-		assert.Equal(t, strings.Join([]string{
-			"Hello World!",
-			"",
-			" - Item 1",
-			" - Item 2",
-			"",
-			" 1) Item 1",
-			" 2) Item 2",
-			"",
-			"This is a paragraph. It is very long so that the text will be wrapped onto a ",
-			"newline. Did it work?",
-			"",
-			"Click Me! (https://yaak.app)",
-		}, "\n"), root.ToText())
+		hagltest.SnapshotWith(t, "to_text_nested", root, hagltest.SnapshotOptions{Pretty: DefaultPrettyOptions(), Text: true})
 	})
 
 	t.Run("doesn't add too much whitespace", func(t *testing.T) {
@@ -98,11 +77,7 @@ func TestElement_ToText(t *testing.T) {
 				),
 			),
 		)
-		assert.Equal(t, strings.Join([]string{
-			"P 1",
-			"",
-			"P 2",
-		}, "\n"), root.ToText())
+		hagltest.SnapshotWith(t, "to_text_whitespace", root, hagltest.SnapshotOptions{Pretty: DefaultPrettyOptions(), Text: true})
 	})
 }
 
@@ -151,20 +126,63 @@ func TestElement_Style(t *testing.T) {
 		assert.Equal(t, `<button style="background:red;color:white"></button>`, root.ToHTML())
 	})
 
-	t.Run("doesn't overwrite style", func(t *testing.T) {
-		root := Button().StyleProperty("background", "red").StyleProperty("background", "blue")
-		assert.Equal(t, `<button style="background:red;background:blue"></button>`, root.ToHTML())
+	t.Run("replaces existing property", func(t *testing.T) {
+		root := Button().StyleProperty("background", "red").StyleProperty("color", "white").StyleProperty("background", "blue")
+		assert.Equal(t, `<button style="background:blue;color:white"></button>`, root.ToHTML())
+	})
+
+	t.Run("merges into manually set style", func(t *testing.T) {
+		root := Button().Style("color: red; margin: 0").StyleProperty("COLOR", "blue")
+		assert.Equal(t, `<button style="color:blue;margin:0"></button>`, root.ToHTML())
+	})
+
+	t.Run("escapes values", func(t *testing.T) {
+		root := Button().StyleProperty("color", "red;position:fixed")
+		assert.Equal(t, `<button style="color:red\;position:fixed"></button>`, root.ToHTML())
+	})
+
+	t.Run("merges when extending", func(t *testing.T) {
+		root := Button().StyleProperty("color", "red").StyleProperty("margin", "0").
+			Extend(Span().StyleProperty("color", "blue"))
+		assert.Equal(t, `<button style="color:blue;margin:0"></button>`, root.ToHTML())
 	})
 }
 
 func TestElement_HTMLPretty(t *testing.T) {
 	t.Run("pre", func(t *testing.T) {
 		root := Pre().HTMLUnsafe("function foo() {\n  return 'Hello World!';\n}")
-		assert.Equal(t, strings.Join([]string{
-			"<pre>function foo() {",
-			"  return 'Hello World!';",
-			"}</pre>",
-		}, "\n"), root.ToHTMLPretty())
+		hagltest.Snapshot(t, "pretty_pre", root)
+	})
+
+	t.Run("syntax highlighted pre", func(t *testing.T) {
+		root := Div().Children(
+			Pre().Children(
+				Code().Class("language-go").Children(
+					Span().Class("kw").Text("func"),
+					Text(" main() {\n  "),
+					Span().Class("fn").Children(
+						Span().Text("println"),
+					),
+					Text("()\n}"),
+				),
+			),
+		)
+		hagltest.Snapshot(t, "pretty_pre_highlighted", root)
+	})
+
+	t.Run("preserves surrounding whitespace in pre", func(t *testing.T) {
+		root := Div().Children(
+			Pre().Text("  indented\n"),
+			Textarea().Children(Text("\n  value\n")),
+		)
+		hagltest.Snapshot(t, "pretty_pre_whitespace", root)
+	})
+
+	t.Run("doesn't modify children of pre", func(t *testing.T) {
+		code := Div().Children(Span().Text("a"), Span().Text("b"))
+		Pre().Children(code)
+
+		hagltest.Snapshot(t, "pretty_pre_children", code)
 	})
 
 	t.Run("pretty HTML", func(t *testing.T) {
@@ -182,19 +200,66 @@ func TestElement_HTMLPretty(t *testing.T) {
 				Comment("That was cool"),
 			),
 		)
-		assert.Equal(t, strings.Join([]string{
-			"<div>",
-			"  <ul>",
-			"    <li>1</li>",
-			"    <li>Hello\n\n\n\nWorld!</li>",
-			"    <li>This is a really long string that will get wrapped because it&#39;s too long.</li>",
-			"  </ul>",
-			"  <pre>function foo() {",
-			"  return &#39;Hello World!&#39;;",
-			"}</pre>",
-			"  <pre><div>foo</div>Bar<h2>woo!</h2><!-- That was cool --></pre>",
-			"</div>",
-		}, "\n"), root.ToHTMLPretty())
+		hagltest.Snapshot(t, "pretty_html", root)
+	})
+}
+
+func TestElement_HTMLPrettyWith(t *testing.T) {
+	t.Run("uses custom indent", func(t *testing.T) {
+		root := Div().Children(
+			Fragment().Children(
+				Ul().Children(Li().Text("Item")),
+			),
+		)
+		hagltest.SnapshotWith(t, "pretty_custom_indent", root, hagltest.SnapshotOptions{Pretty: PrettyOptions{Indent: "\t"}})
+	})
+
+	t.Run("wraps long attribute lists", func(t *testing.T) {
+		root := Form().Children(
+			Input().Type("email").Name("email").Attr("placeholder", "you@example.com"),
+			Button().Type("submit").Text("Go"),
+		)
+		hagltest.SnapshotWith(t, "pretty_wrap_attributes", root, hagltest.SnapshotOptions{Pretty: PrettyOptions{Indent: "  ", MaxWidth: 40, WrapAttributes: true}})
+	})
+
+	t.Run("doesn't wrap without WrapAttributes", func(t *testing.T) {
+		root := Input().Type("email").Name("email").Attr("placeholder", "you@example.com")
+		assert.Equal(t, root.ToHTML(), root.ToHTMLPrettyWith(PrettyOptions{MaxWidth: 10}))
+	})
+}
+
+func TestElement_HTMLPretty_Inline(t *testing.T) {
+	t.Run("keeps inline content on one line", func(t *testing.T) {
+		root := P().Children(Text("Hello "), Strong().Text("world"), Text("!"))
+		assert.Equal(t, `<p>Hello <strong>world</strong>!</p>`, root.ToHTMLPretty())
+	})
+
+	t.Run("separates inline runs from blocks", func(t *testing.T) {
+		root := Div().Children(
+			Text("Intro "),
+			Em().Text("text"),
+			Div().Text("Block"),
+			A().Href("/").Text("Home"),
+			Span().Text(" | "),
+			A().Href("/about").Text("About"),
+		)
+		hagltest.Snapshot(t, "pretty_inline_runs", root)
+	})
+
+	t.Run("doesn't add whitespace inside inline elements", func(t *testing.T) {
+		root := Div().Children(
+			Span().Children(Strong().Text("a"), Em().Text("b")),
+		)
+		hagltest.Snapshot(t, "pretty_inline_whitespace", root)
+	})
+
+	t.Run("treats custom elements as inline", func(t *testing.T) {
+		root := P().Children(
+			Text("Status: "),
+			NewElement("status-badge").Children(Strong().Text("ok")),
+			Text("!"),
+		)
+		assert.Equal(t, `<p>Status: <status-badge><strong>ok</strong></status-badge>!</p>`, root.ToHTMLPretty())
 	})
 }
 
@@ -246,14 +311,7 @@ func TestComment(t *testing.T) {
 				Comment("This is an awesome comment"),
 			),
 		)
-		assert.Equal(t, strings.Join([]string{
-			"<div>",
-			"  <!-- This is in a div -->",
-			"  <div>",
-			"    <!-- This is an awesome comment -->",
-			"  </div>",
-			"</div>",
-		}, "\n"), root.ToHTMLPretty())
+		hagltest.Snapshot(t, "comment_nested", root)
 	})
 }
 
@@ -284,13 +342,7 @@ func TestFragment(t *testing.T) {
 			Div(),
 			Div(),
 		)
-		assert.Equal(t, strings.Join([]string{
-			"<div>",
-			"  <span>foo</span>",
-			"</div>",
-			"<div></div>",
-			"<div></div>",
-		}, "\n"), root.ToHTMLPretty())
+		hagltest.Snapshot(t, "fragment_pretty", root)
 	})
 
 	t.Run("pretty basic example wrapped", func(t *testing.T) {
@@ -304,16 +356,7 @@ func TestFragment(t *testing.T) {
 				),
 			),
 		)
-		assert.Equal(t, strings.Join([]string{
-			"<div>",
-			"  <div>",
-			"    <span>foo</span>",
-			"  </div>",
-			"  <div></div>",
-			"  <h1>Hi</h1>",
-			"  <h2></h2>",
-			"</div>",
-		}, "\n"), root.ToHTMLPretty())
+		hagltest.Snapshot(t, "fragment_pretty_wrapped", root)
 	})
 }
 
@@ -0,0 +1,71 @@
+package hagl
+
+import (
+	"strings"
+)
+
+// PrettyOptions configures the indented output of ToHTMLPrettyWith. The
+// same options are used for every node in the tree.
+type PrettyOptions struct {
+	// Indent is the string used for each level of indentation
+	Indent string
+
+	// MaxWidth is the width at which an opening tag, including its
+	// indentation, is considered too long. Zero or less means no limit.
+	MaxWidth int
+
+	// WrapAttributes puts each attribute of an opening tag that's wider
+	// than MaxWidth on its own line
+	WrapAttributes bool
+
+	// inline is set when rendering inline content, where only whitespace
+	// inside of tags may be added
+	inline bool
+}
+
+// DefaultPrettyOptions returns the options used by ToHTMLPretty
+func DefaultPrettyOptions() PrettyOptions {
+	return PrettyOptions{
+		Indent: "  ",
+	}
+}
+
+// inlined returns a copy of the options for rendering inline content
+func (o *PrettyOptions) inlined() *PrettyOptions {
+	inlined := *o
+	inlined.inline = true
+	return &inlined
+}
+
+func (o *PrettyOptions) indent(level int, text string) string {
+	if level <= 0 {
+		return text
+	}
+
+	return strings.Repeat(o.Indent, level) + text
+}
+
+// openTag renders an opening tag ending with end, which is either ">" or
+// "/>". The tag is wrapped onto multiple lines if it's too wide.
+func (o *PrettyOptions) openTag(level int, tag string, attrs []string, end string) string {
+	tagStr := "<" + tag
+	for _, a := range attrs {
+		tagStr += " " + a
+	}
+
+	if o == nil || !o.WrapAttributes || o.MaxWidth <= 0 || len(attrs) == 0 {
+		return tagStr + end
+	}
+
+	if textWidth(o.indent(level, tagStr+end)) <= o.MaxWidth {
+		return tagStr + end
+	}
+
+	lines := []string{"<" + tag}
+	for _, a := range attrs {
+		lines = append(lines, o.indent(level+1, a))
+	}
+	lines = append(lines, o.indent(level, end))
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,315 @@
+package hagl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// errUnsupportedSelector is returned for valid selectors that can't be
+// matched against a tree, like those with pseudo-classes
+var errUnsupportedSelector = errors.New("unsupported selector")
+
+// cssSelector is a complex selector like "ul.nav > li a[href]". It supports
+// type, universal, class, ID and attribute selectors, joined by descendant
+// and child combinators.
+type cssSelector struct {
+	compounds []cssCompound
+
+	// combinators[i] joins compounds[i] and compounds[i+1], and is either
+	// ' ' for descendants or '>' for children
+	combinators []byte
+
+	// specificity is the number of ID, class and type selectors
+	specificity [3]int
+}
+
+type cssCompound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []cssAttrSelector
+}
+
+type cssAttrSelector struct {
+	name  string
+	op    string
+	value string
+}
+
+func parseSelector(sel string) (*cssSelector, error) {
+	var (
+		s     = &cssSelector{}
+		cur   cssCompound
+		empty = true
+		comb  byte
+	)
+
+	flush := func() {
+		s.compounds = append(s.compounds, cur)
+		cur = cssCompound{}
+		empty = true
+	}
+
+	for i := 0; i < len(sel); {
+		c := sel[i]
+
+		switch {
+		case isHTMLSpace(rune(c)):
+			if !empty {
+				flush()
+			}
+			if comb == 0 {
+				comb = ' '
+			}
+			i++
+			continue
+		case c == '>':
+			if !empty {
+				flush()
+			}
+			if len(s.compounds) == 0 {
+				return nil, fmt.Errorf("invalid selector %q", sel)
+			}
+			comb = '>'
+			i++
+			continue
+		case c == '+' || c == '~' || c == ':':
+			return nil, errUnsupportedSelector
+		}
+
+		// Starting a new compound selector after a combinator
+		if empty && len(s.compounds) > 0 {
+			s.combinators = append(s.combinators, comb)
+			comb = 0
+		}
+
+		var (
+			ident string
+			err   error
+		)
+
+		switch {
+		case c == '*' && empty:
+			i++
+		case c == '#':
+			ident, i, err = readCSSIdent(sel, i+1)
+			cur.id = ident
+			s.specificity[0]++
+		case c == '.':
+			ident, i, err = readCSSIdent(sel, i+1)
+			cur.classes = append(cur.classes, ident)
+			s.specificity[1]++
+		case c == '[':
+			var a cssAttrSelector
+			a, i, err = readCSSAttrSelector(sel, i+1)
+			cur.attrs = append(cur.attrs, a)
+			s.specificity[1]++
+		case empty:
+			ident, i, err = readCSSIdent(sel, i)
+			cur.tag = strings.ToLower(ident)
+			s.specificity[2]++
+		default:
+			err = errors.New("unexpected character")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", sel, err)
+		}
+		empty = false
+	}
+
+	if !empty {
+		flush()
+	}
+
+	// A selector can't end with a combinator like "ul >"
+	if len(s.compounds) == 0 || comb == '>' {
+		return nil, fmt.Errorf("invalid selector %q", sel)
+	}
+
+	return s, nil
+}
+
+// readCSSIdent reads an identifier starting at i, returning it along with
+// the index after it
+func readCSSIdent(s string, i int) (string, int, error) {
+	var b strings.Builder
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == '-' || c == '_' || c >= 0x80 ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'):
+			b.WriteByte(c)
+		default:
+			if b.Len() == 0 {
+				return "", i, errors.New("expected a name")
+			}
+			return b.String(), i, nil
+		}
+	}
+
+	if b.Len() == 0 {
+		return "", i, errors.New("expected a name")
+	}
+	return b.String(), i, nil
+}
+
+// readCSSAttrSelector reads an attribute selector like [href^="https:"],
+// starting after the opening bracket
+func readCSSAttrSelector(s string, i int) (cssAttrSelector, int, error) {
+	var (
+		a   cssAttrSelector
+		err error
+	)
+
+	skipSpace := func() {
+		for i < len(s) && isHTMLSpace(rune(s[i])) {
+			i++
+		}
+	}
+
+	skipSpace()
+	if a.name, i, err = readCSSIdent(s, i); err != nil {
+		return a, i, err
+	}
+	a.name = strings.ToLower(a.name)
+	skipSpace()
+
+	for _, op := range []string{"=", "~=", "|=", "^=", "$=", "*="} {
+		if strings.HasPrefix(s[i:], op) {
+			a.op = op
+			i += len(op)
+			break
+		}
+	}
+
+	if a.op != "" {
+		skipSpace()
+		if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+			end := skipCSSString(s, i)
+			if end >= len(s) {
+				return a, i, errors.New("unterminated string")
+			}
+			a.value = unescapeCSS(s[i+1 : end])
+			i = end + 1
+		} else if a.value, i, err = readCSSIdent(s, i); err != nil {
+			return a, i, err
+		}
+		skipSpace()
+	}
+
+	if i >= len(s) || s[i] != ']' {
+		return a, i, errors.New("expected ]")
+	}
+
+	return a, i + 1, nil
+}
+
+func unescapeCSS(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// matches returns whether the selector matches n, whose ancestor elements
+// are given from the root down
+func (s *cssSelector) matches(n *RawNode, ancestors []*RawNode) bool {
+	return s.matchFrom(len(s.compounds)-1, n, ancestors)
+}
+
+func (s *cssSelector) matchFrom(i int, n *RawNode, ancestors []*RawNode) bool {
+	if !s.compounds[i].matches(n) {
+		return false
+	}
+
+	if i == 0 {
+		return true
+	}
+
+	if s.combinators[i-1] == '>' {
+		return len(ancestors) > 0 &&
+			s.matchFrom(i-1, ancestors[len(ancestors)-1], ancestors[:len(ancestors)-1])
+	}
+
+	for j := len(ancestors) - 1; j >= 0; j-- {
+		if s.matchFrom(i-1, ancestors[j], ancestors[:j]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *cssCompound) matches(n *RawNode) bool {
+	if n.nodeType != tagNode {
+		return false
+	}
+
+	if c.tag != "" && !strings.EqualFold(c.tag, n.tag) {
+		return false
+	}
+
+	if c.id != "" && n.attr("id") != c.id {
+		return false
+	}
+
+	classes := strings.Fields(n.attr("class"))
+	for _, cls := range c.classes {
+		if !slices.Contains(classes, cls) {
+			return false
+		}
+	}
+
+	for _, a := range c.attrs {
+		if !a.matches(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *cssAttrSelector) matches(n *RawNode) bool {
+	var (
+		value string
+		found bool
+	)
+
+	for _, attr := range n.attrs {
+		if strings.EqualFold(attr.name, a.name) {
+			value, found = attr.value, true
+		}
+	}
+
+	switch {
+	case !found:
+		return false
+	case a.op == "":
+		return true
+	case a.op == "=":
+		return value == a.value
+	case a.op == "~=":
+		return slices.Contains(strings.Fields(value), a.value)
+	case a.op == "|=":
+		return value == a.value || strings.HasPrefix(value, a.value+"-")
+	case a.value == "":
+		// The remaining operators never match an empty value
+		return false
+	case a.op == "^=":
+		return strings.HasPrefix(value, a.value)
+	case a.op == "$=":
+		return strings.HasSuffix(value, a.value)
+	default:
+		return strings.Contains(value, a.value)
+	}
+}
@@ -0,0 +1,302 @@
+package hagl
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Styles is a set of CSS properties for the style attribute of an element.
+// Setting a property that's already set replaces its value in the same
+// position, so styles always render in the order they were first set.
+//
+//	Div().Styles(CSS().Display(DisplayFlex).Gap(Px(8)).Margin(Rem(1), Auto))
+type Styles struct {
+	decls []cssDeclaration
+}
+
+// CSS creates an empty set of styles
+func CSS() *Styles {
+	return &Styles{}
+}
+
+// parseStyles parses the value of a style attribute
+func parseStyles(value string) *Styles {
+	s := CSS()
+	for _, d := range parseCSSDeclarations(value) {
+		s.set(d)
+	}
+	return s
+}
+
+// Set sets a property, escaping the value so it can't end the declaration.
+// A value ending in !important makes the declaration important.
+func (s *Styles) Set(name, value string) *Styles {
+	if d, ok := newCSSDeclaration(name, escapeCSSValue(value)); ok {
+		s.set(d)
+	}
+	return s
+}
+
+func (s *Styles) set(d cssDeclaration) {
+	for i, existing := range s.decls {
+		if existing.name == d.name {
+			s.decls[i] = d
+			return
+		}
+	}
+	s.decls = append(s.decls, d)
+}
+
+// Get returns the value of a property, or an empty string if it isn't set
+func (s *Styles) Get(name string) string {
+	for _, d := range s.decls {
+		if d.name == name {
+			return d.value
+		}
+	}
+	return ""
+}
+
+// Merge sets every property of other, replacing those already set
+func (s *Styles) Merge(other *Styles) *Styles {
+	for _, d := range other.decls {
+		s.set(d)
+	}
+	return s
+}
+
+// String renders the styles for a style attribute, like "color:red;gap:8px"
+func (s *Styles) String() string {
+	items := make([]string, len(s.decls))
+	for i, d := range s.decls {
+		items[i] = d.String()
+	}
+	return strings.Join(items, ";")
+}
+
+// Length is a CSS length, like 8px or 1rem
+type Length string
+
+// Auto is the auto length, which lets the browser choose
+const Auto Length = "auto"
+
+func Px(n float64) Length {
+	return unit(n, "px")
+}
+
+func Rem(n float64) Length {
+	return unit(n, "rem")
+}
+
+// Ems is a length relative to the font size. It isn't named Em, since that
+// creates an <em> element.
+func Ems(n float64) Length {
+	return unit(n, "em")
+}
+
+func Percent(n float64) Length {
+	return unit(n, "%")
+}
+
+func Vw(n float64) Length {
+	return unit(n, "vw")
+}
+
+func Vh(n float64) Length {
+	return unit(n, "vh")
+}
+
+func unit(n float64, unit string) Length {
+	if n == 0 {
+		return "0"
+	}
+	return Length(strconv.FormatFloat(n, 'f', -1, 64) + unit)
+}
+
+func lengths(values []Length) string {
+	items := make([]string, len(values))
+	for i, v := range values {
+		items[i] = string(v)
+	}
+	return strings.Join(items, " ")
+}
+
+// Display is a value of the display property
+type Display string
+
+const (
+	DisplayBlock       Display = "block"
+	DisplayInline      Display = "inline"
+	DisplayInlineBlock Display = "inline-block"
+	DisplayFlex        Display = "flex"
+	DisplayInlineFlex  Display = "inline-flex"
+	DisplayGrid        Display = "grid"
+	DisplayContents    Display = "contents"
+	DisplayNone        Display = "none"
+)
+
+// Position is a value of the position property
+type Position string
+
+const (
+	PositionStatic   Position = "static"
+	PositionRelative Position = "relative"
+	PositionAbsolute Position = "absolute"
+	PositionFixed    Position = "fixed"
+	PositionSticky   Position = "sticky"
+)
+
+func (s *Styles) Display(value Display) *Styles {
+	return s.Set("display", string(value))
+}
+
+func (s *Styles) Position(value Position) *Styles {
+	return s.Set("position", string(value))
+}
+
+func (s *Styles) Top(value Length) *Styles {
+	return s.Set("top", string(value))
+}
+
+func (s *Styles) Right(value Length) *Styles {
+	return s.Set("right", string(value))
+}
+
+func (s *Styles) Bottom(value Length) *Styles {
+	return s.Set("bottom", string(value))
+}
+
+func (s *Styles) Left(value Length) *Styles {
+	return s.Set("left", string(value))
+}
+
+func (s *Styles) Width(value Length) *Styles {
+	return s.Set("width", string(value))
+}
+
+func (s *Styles) Height(value Length) *Styles {
+	return s.Set("height", string(value))
+}
+
+func (s *Styles) MinWidth(value Length) *Styles {
+	return s.Set("min-width", string(value))
+}
+
+func (s *Styles) MaxWidth(value Length) *Styles {
+	return s.Set("max-width", string(value))
+}
+
+func (s *Styles) MinHeight(value Length) *Styles {
+	return s.Set("min-height", string(value))
+}
+
+func (s *Styles) MaxHeight(value Length) *Styles {
+	return s.Set("max-height", string(value))
+}
+
+// Margin sets the margin, taking one to four values like the CSS shorthand
+func (s *Styles) Margin(values ...Length) *Styles {
+	return s.Set("margin", lengths(values))
+}
+
+// Padding sets the padding, taking one to four values like the CSS shorthand
+func (s *Styles) Padding(values ...Length) *Styles {
+	return s.Set("padding", lengths(values))
+}
+
+// Gap sets the gap between rows and columns, or each separately when given
+// two values
+func (s *Styles) Gap(values ...Length) *Styles {
+	return s.Set("gap", lengths(values))
+}
+
+func (s *Styles) Flex(value string) *Styles {
+	return s.Set("flex", value)
+}
+
+func (s *Styles) FlexDirection(value string) *Styles {
+	return s.Set("flex-direction", value)
+}
+
+func (s *Styles) FlexWrap(value string) *Styles {
+	return s.Set("flex-wrap", value)
+}
+
+func (s *Styles) JustifyContent(value string) *Styles {
+	return s.Set("justify-content", value)
+}
+
+func (s *Styles) AlignItems(value string) *Styles {
+	return s.Set("align-items", value)
+}
+
+func (s *Styles) Color(value string) *Styles {
+	return s.Set("color", value)
+}
+
+func (s *Styles) Background(value string) *Styles {
+	return s.Set("background", value)
+}
+
+func (s *Styles) BackgroundColor(value string) *Styles {
+	return s.Set("background-color", value)
+}
+
+func (s *Styles) Border(value string) *Styles {
+	return s.Set("border", value)
+}
+
+// BorderRadius sets the border radius, taking one to four values like the
+// CSS shorthand
+func (s *Styles) BorderRadius(values ...Length) *Styles {
+	return s.Set("border-radius", lengths(values))
+}
+
+// FontFamily sets the font family, quoting names that aren't keywords
+func (s *Styles) FontFamily(names ...string) *Styles {
+	families := make([]string, len(names))
+	for i, name := range names {
+		if strings.Trim(name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-") == "" {
+			families[i] = name
+		} else {
+			families[i] = `"` + strings.ReplaceAll(name, `"`, `\"`) + `"`
+		}
+	}
+	return s.Set("font-family", strings.Join(families, ", "))
+}
+
+func (s *Styles) FontSize(value Length) *Styles {
+	return s.Set("font-size", string(value))
+}
+
+func (s *Styles) FontWeight(value int) *Styles {
+	return s.Set("font-weight", strconv.Itoa(value))
+}
+
+func (s *Styles) LineHeight(value string) *Styles {
+	return s.Set("line-height", value)
+}
+
+func (s *Styles) TextAlign(value string) *Styles {
+	return s.Set("text-align", value)
+}
+
+func (s *Styles) TextDecoration(value string) *Styles {
+	return s.Set("text-decoration", value)
+}
+
+func (s *Styles) Overflow(value string) *Styles {
+	return s.Set("overflow", value)
+}
+
+func (s *Styles) Cursor(value string) *Styles {
+	return s.Set("cursor", value)
+}
+
+func (s *Styles) Opacity(value float64) *Styles {
+	return s.Set("opacity", strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (s *Styles) ZIndex(value int) *Styles {
+	return s.Set("z-index", strconv.Itoa(value))
+}
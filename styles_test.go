@@ -0,0 +1,50 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestStyles(t *testing.T) {
+	t.Run("renders typed properties", func(t *testing.T) {
+		root := Div().Styles(
+			CSS().
+				Display(DisplayFlex).
+				Gap(Px(8)).
+				Margin(Rem(1), Auto).
+				Padding(Px(0), Ems(0.5)).
+				FontFamily("Helvetica Neue", "sans-serif").
+				FontWeight(600).
+				Opacity(0.5),
+		)
+		assert.Equal(t, `<div style="display:flex;gap:8px;margin:1rem auto;padding:0 0.5em;`+
+			`font-family:&#34;Helvetica Neue&#34;, sans-serif;font-weight:600;opacity:0.5"></div>`, root.ToHTML())
+	})
+
+	t.Run("merges by name with later values winning", func(t *testing.T) {
+		base := CSS().Color("red").Width(Percent(100))
+		root := Div().Styles(base).Styles(CSS().Color("blue").Height(Vh(50)))
+		assert.Equal(t, `<div style="color:blue;width:100%;height:50vh"></div>`, root.ToHTML())
+	})
+
+	t.Run("keeps important declarations", func(t *testing.T) {
+		s := CSS().Set("color", "red !important").Set("--Brand-Color", "#f00")
+		assert.Equal(t, "color:red !important;--Brand-Color:#f00", s.String())
+		assert.Equal(t, "red", s.Get("color"))
+	})
+
+	t.Run("escapes values and skips invalid names", func(t *testing.T) {
+		s := CSS().
+			Set("background", "url(a.png)}body{color:red").
+			Set("content", `"\201C"`).
+			Set("color: red; x", "blue").
+			Set("margin", "")
+		assert.Equal(t, `background:url(a.png)\}body\{color:red;content:"\201C"`, s.String())
+	})
+
+	t.Run("renders nothing when empty", func(t *testing.T) {
+		assert.Equal(t, "<div></div>", Div().Styles(CSS()).ToHTML())
+	})
+}
@@ -0,0 +1,112 @@
+package hagl
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// Stylesheet is CSS for a component. It's rendered once into a <style> in
+// the <head> of the Document, no matter how many times the component is
+// rendered.
+//
+//	var cardStyles = NewScopedStylesheet(`
+//		:scope { padding: 1rem }
+//		h2 { font-size: 1.5rem }
+//	`)
+//
+//	var Card = NewComponent(func(children []Node) Node {
+//		return cardStyles.Apply(Div().Children(children...))
+//	})
+type Stylesheet struct {
+	css   string
+	class string
+}
+
+// NewStylesheet creates a stylesheet that is rendered as-is
+func NewStylesheet(css string) *Stylesheet {
+	return &Stylesheet{css: strings.TrimSpace(css)}
+}
+
+// NewScopedStylesheet creates a stylesheet whose rules only apply to
+// elements inside an element with its Class. The class is generated from
+// the CSS, and :scope in a selector refers to the element with the class.
+func NewScopedStylesheet(css string) *Stylesheet {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(css))
+	class := fmt.Sprintf("hagl-%08x", h.Sum32())
+
+	// Invalid rules are skipped, like a browser would
+	rules, _ := parseCSSRules(css)
+	return &Stylesheet{
+		css:   scopeCSS(rules, class),
+		class: class,
+	}
+}
+
+// Class returns the class that scopes the stylesheet, or an empty string
+// if it isn't scoped
+func (s *Stylesheet) Class() string {
+	return s.class
+}
+
+// CSS returns the CSS of the stylesheet, after scoping
+func (s *Stylesheet) CSS() string {
+	return s.css
+}
+
+// Node returns a node that adds the stylesheet to the <head> of the
+// Document it's rendered in. It renders nothing where it's placed.
+func (s *Stylesheet) Node() Node {
+	return newHeadNode(Style().HTMLUnsafe(s.css))
+}
+
+// Apply adds the class of the stylesheet to n, along with the node that
+// adds the stylesheet to the <head>
+func (s *Stylesheet) Apply(n Node) Node {
+	if s.class != "" {
+		n.Class(s.class)
+	}
+	return n.Children(s.Node())
+}
+
+// scopeCSS prefixes the selectors of each rule with the class, so they only
+// match inside elements with it
+func scopeCSS(rules []cssRule, class string) string {
+	var out []string
+	for _, r := range rules {
+		switch {
+		case r.isNestedAtRule():
+			rules, _ := parseCSSRules(r.block)
+			r.block = scopeCSS(rules, class)
+		case r.hasBlock && !strings.HasPrefix(r.prelude, "@"):
+			selectors := splitCSSList(r.prelude)
+			for i, sel := range selectors {
+				selectors[i] = scopeSelector(sel, class)
+			}
+			r.prelude = strings.Join(selectors, ", ")
+		}
+
+		out = append(out, r.String())
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func scopeSelector(sel, class string) string {
+	if strings.Contains(sel, ":scope") {
+		return strings.ReplaceAll(sel, ":scope", "."+class)
+	}
+	return "." + class + " " + sel
+}
+
+// styleText returns the CSS inside a <style> element
+func styleText(n *RawNode) string {
+	var b strings.Builder
+	for _, c := range n.children {
+		if c := c.GetNode(); c.nodeType == textNode {
+			b.WriteString(c.text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestStylesheet(t *testing.T) {
+	t.Run("renders once into the head", func(t *testing.T) {
+		styles := NewStylesheet(".card { padding: 1rem }")
+		card := NewComponent(func(children []Node) Node {
+			return styles.Apply(Div().Class("card").Children(children...))
+		})
+
+		root := Document(DocumentOptions{}).Children(
+			card().Text("One"),
+			card().Text("Two"),
+			NewStylesheet("body { margin: 0 }").Node(),
+		)
+
+		assert.Equal(t, `<!DOCTYPE html><html><head>`+
+			`<meta charset="utf-8"/>`+
+			`<meta name="viewport" content="width=device-width, initial-scale=1"/>`+
+			"<style>.card { padding: 1rem }\nbody { margin: 0 }</style>"+
+			`</head><body>`+
+			`<div class="card">One</div><div class="card">Two</div>`+
+			`</body></html>`, root.ToHTML())
+	})
+
+	t.Run("scopes selectors", func(t *testing.T) {
+		styles := NewScopedStylesheet(`
+			/* The card itself */
+			:scope { padding: 1rem }
+			h2, :scope > p { color: red }
+			a[title="a, b"] { color: blue }
+			@media (max-width: 600px) {
+				:scope { padding: 0 }
+			}
+			@keyframes spin { to { transform: rotate(360deg) } }
+		`)
+
+		cls := styles.Class()
+		assert.True(t, strings.HasPrefix(cls, "hagl-"))
+		assert.Equal(t, strings.Join([]string{
+			"." + cls + " { padding: 1rem }",
+			"." + cls + " h2, ." + cls + " > p { color: red }",
+			"." + cls + ` a[title="a, b"] { color: blue }`,
+			"@media (max-width: 600px) { ." + cls + " { padding: 0 } }",
+			"@keyframes spin { to { transform: rotate(360deg) } }",
+		}, "\n"), styles.CSS())
+
+		root := styles.Apply(Div())
+		assert.Equal(t, `<div class="`+cls+`"></div>`, root.ToHTML())
+	})
+
+	t.Run("generates the same class for the same CSS", func(t *testing.T) {
+		a := NewScopedStylesheet("p { color: red }")
+		b := NewScopedStylesheet("p { color: red }")
+		c := NewScopedStylesheet("p { color: blue }")
+		assert.Equal(t, a.Class(), b.Class())
+		assert.NotEqual(t, a.Class(), c.Class())
+		assert.Equal(t, "", NewStylesheet("p { color: red }").Class())
+	})
+}
@@ -1,28 +1,609 @@
 package hagl
 
 import (
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-func WrapText(text string, lineLength int) string {
-	words := strings.Split(text, " ")
-	var wrappedLines []string
-	currentLine := ""
+// LinkStyle defines how the href of an <a> element is rendered as text
+type LinkStyle int
 
-	for i, word := range words {
-		if len(currentLine)+len(word)+1 > lineLength {
-			wrappedLines = append(wrappedLines, currentLine)
-			currentLine = ""
+const (
+	// LinkInline renders the href in parentheses after the link text
+	LinkInline LinkStyle = iota
+
+	// LinkReference renders a numbered reference like [1] after the link
+	// text and lists all referenced URLs at the bottom of the output
+	LinkReference
+
+	// LinkHidden renders only the link text
+	LinkHidden
+)
+
+// HeadingStyle defines how h1-h6 elements are decorated when rendered as text
+type HeadingStyle int
+
+const (
+	// HeadingPlain renders headings like any other block of text
+	HeadingPlain HeadingStyle = iota
+
+	// HeadingUnderline underlines h1 with "=" and other headings with "-"
+	HeadingUnderline
+
+	// HeadingHashes prefixes headings with one "#" per level, like Markdown
+	HeadingHashes
+)
+
+// TextOptions configures the plain text output of ToTextWith
+type TextOptions struct {
+	// LineWidth is the column at which text is wrapped. A value of zero or
+	// less disables wrapping.
+	LineWidth int
+
+	// Links defines how links are rendered
+	Links LinkStyle
+
+	// Bullets are the markers used for unordered list items. Nested lists
+	// cycle through them by depth. Defaults to "-" when empty.
+	Bullets []string
+
+	// Headings defines how headings are decorated
+	Headings HeadingStyle
+}
+
+// DefaultTextOptions returns the options used by ToText
+func DefaultTextOptions() TextOptions {
+	return TextOptions{
+		LineWidth: 80,
+		Links:     LinkInline,
+		Bullets:   []string{"-"},
+		Headings:  HeadingPlain,
+	}
+}
+
+type textRenderer struct {
+	opts TextOptions
+
+	// links holds the URLs collected when rendering with LinkReference
+	links []string
+
+	// listDepth is the number of lists currently being rendered
+	listDepth int
+
+	// ansi enables terminal escape sequences for styling text
+	ansi bool
+}
+
+func newTextRenderer(opts TextOptions) *textRenderer {
+	return &textRenderer{opts: opts}
+}
+
+func (r *textRenderer) render(rn *RawNode) string {
+	text := strings.Trim(r.blocks([]Node{rn}, r.opts.LineWidth, "\n\n"), "\n")
+
+	if len(r.links) > 0 {
+		refs := make([]string, len(r.links))
+		for i, href := range r.links {
+			refs[i] = fmt.Sprintf("[%d] %s", i+1, href)
+		}
+		text += "\n\n" + strings.Join(refs, "\n")
+	}
+
+	return strings.Trim(text, "\n")
+}
+
+// invisibleEls are elements whose content is never displayed, so they're
+// skipped when rendering text
+var invisibleEls = []string{"head", "script", "style", "template"}
+
+// eachVisible calls fn for every visible child, descending into fragments so
+// their children are treated as siblings of the surrounding nodes
+func eachVisible(nodes []Node, fn func(n *RawNode)) {
+	for _, c := range nodes {
+		n := c.GetNode()
+		switch {
+		case n.hide:
+		case n.nodeType == commentNode, n.nodeType == doctypeNode, n.nodeType == headNode:
+		case n.nodeType == tagNode && slices.Contains(invisibleEls, n.tag):
+		case n.nodeType == fragmentNode:
+			eachVisible(n.children, fn)
+		default:
+			fn(n)
+		}
+	}
+}
+
+// isTextBlock returns whether the node should start a new block of text. Inline
+// elements that wrap block elements are treated as blocks themselves.
+func isTextBlock(n *RawNode) bool {
+	if n.nodeType != tagNode {
+		return false
+	}
+
+	if n.isBlock() {
+		return true
+	}
+
+	found := false
+	eachVisible(n.children, func(c *RawNode) {
+		found = found || isTextBlock(c)
+	})
+
+	return found
+}
+
+// blocks renders sibling nodes, grouping consecutive inline nodes into
+// wrapped paragraphs, and joins the resulting blocks with sep
+func (r *textRenderer) blocks(nodes []Node, width int, sep string) string {
+	var (
+		out    []string
+		inline strings.Builder
+	)
+
+	flush := func() {
+		if s := r.wrap(inline.String(), width); s != "" {
+			out = append(out, s)
+		}
+		inline.Reset()
+	}
+
+	eachVisible(nodes, func(n *RawNode) {
+		if !isTextBlock(n) {
+			r.inline(n, &inline)
+			return
+		}
+
+		flush()
+		if s := r.block(n, width); s != "" {
+			out = append(out, s)
+		}
+	})
+	flush()
+
+	return strings.Join(out, sep)
+}
+
+func (r *textRenderer) block(n *RawNode, width int) string {
+	switch n.tag {
+	case "ul", "ol":
+		return r.list(n, width)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return r.heading(n, width)
+	case "li":
+		return r.blocks(n.children, width, "\n")
+	case "blockquote":
+		return r.blockquote(n, width)
+	case "pre":
+		return r.pre(n)
+	case "hr":
+		return r.rule(width)
+	case "table":
+		return r.table(n)
+	default:
+		return r.blocks(n.children, width, "\n\n")
+	}
+}
+
+// list renders list items with their markers, indenting the lines of each
+// item (including nested lists) to line up after its marker
+func (r *textRenderer) list(n *RawNode, width int) string {
+	r.listDepth++
+	defer func() { r.listDepth-- }()
+
+	var (
+		nodes   []*RawNode
+		markers []string
+		items   []string
+	)
+
+	eachVisible(n.children, func(c *RawNode) {
+		nodes = append(nodes, c)
+	})
+
+	if n.tag == "ol" {
+		markers = r.numbers(n, nodes)
+	} else {
+		markers = make([]string, len(nodes))
+		for i, c := range nodes {
+			if c.tag == "li" {
+				markers[i] = r.bullet()
+			}
+		}
+	}
+
+	// Pad markers so that the content of every item lines up
+	markerWidth := 0
+	for i, m := range markers {
+		if m != "" {
+			markers[i] = " " + m + " "
+			markerWidth = maxInt(markerWidth, textWidth(markers[i]))
+		}
+	}
+
+	itemWidth := width
+	if width > 0 {
+		itemWidth = maxInt(width-markerWidth, 1)
+	}
+
+	indent := strings.Repeat(" ", markerWidth)
+	for i, c := range nodes {
+		if markers[i] == "" {
+			if s := r.blocks([]Node{c}, width, "\n"); s != "" {
+				items = append(items, s)
+			}
+			continue
+		}
+
+		lines := strings.Split(r.blocks(c.children, itemWidth, "\n"), "\n")
+		for j, line := range lines {
+			if j == 0 {
+				lines[j] = strings.Repeat(" ", markerWidth-textWidth(markers[i])) + markers[i] + line
+			} else if line != "" {
+				lines[j] = indent + line
+			}
+		}
+
+		items = append(items, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// numbers returns the markers for the items of an ordered list, honoring
+// its start, reversed and type attributes and the value attribute of items
+func (r *textRenderer) numbers(n *RawNode, nodes []*RawNode) []string {
+	markers := make([]string, len(nodes))
+
+	count := 0
+	for _, c := range nodes {
+		if c.tag == "li" {
+			count++
+		}
+	}
+
+	step, num := 1, 1
+	if n.hasAttr("reversed") {
+		step, num = -1, count
+	}
+
+	if start, err := strconv.Atoi(n.attr("start")); err == nil {
+		num = start
+	}
+
+	for i, c := range nodes {
+		if c.tag != "li" {
+			continue
+		}
+
+		if value, err := strconv.Atoi(c.attr("value")); err == nil {
+			num = value
+		}
+
+		markers[i] = formatListNumber(num, n.attr("type")) + ")"
+		num += step
+	}
+
+	return markers
+}
+
+// formatListNumber formats n like a browser would for the given <ol> type
+func formatListNumber(n int, typ string) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+
+	switch typ {
+	case "a":
+		return strings.ToLower(alphaNumber(n))
+	case "A":
+		return alphaNumber(n)
+	case "i":
+		return strings.ToLower(romanNumber(n))
+	case "I":
+		return romanNumber(n)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// alphaNumber converts n to A, B, ... Z, AA, AB, ...
+func alphaNumber(n int) string {
+	s := ""
+	for n > 0 {
+		n--
+		s = string(rune('A'+n%26)) + s
+		n /= 26
+	}
+	return s
+}
+
+func romanNumber(n int) string {
+	if n >= 4000 {
+		return strconv.Itoa(n)
+	}
+
+	var (
+		values  = []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+		symbols = []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+		b       strings.Builder
+	)
+
+	for i, v := range values {
+		for n >= v {
+			b.WriteString(symbols[i])
+			n -= v
 		}
-		currentLine += word
-		if i != len(words)-1 {
-			currentLine += " "
+	}
+
+	return b.String()
+}
+
+func (r *textRenderer) bullet() string {
+	if len(r.opts.Bullets) == 0 {
+		return "-"
+	}
+
+	return r.opts.Bullets[(r.listDepth-1)%len(r.opts.Bullets)]
+}
+
+func (r *textRenderer) heading(n *RawNode, width int) string {
+	prefix := ""
+	if r.opts.Headings == HeadingHashes {
+		prefix = strings.Repeat("#", int(n.tag[1]-'0')) + " "
+		if width > 0 {
+			width = maxInt(width-len(prefix), 1)
 		}
 	}
 
-	if currentLine != "" {
-		wrappedLines = append(wrappedLines, currentLine)
+	text := r.blocks(n.children, width, "\n")
+	if text == "" {
+		return ""
 	}
 
-	return strings.Join(wrappedLines, "\n")
+	text = r.styleLines(n, text)
+
+	switch r.opts.Headings {
+	case HeadingUnderline:
+		underline := "-"
+		if n.tag == "h1" {
+			underline = "="
+		}
+
+		longest := 0
+		for _, line := range strings.Split(text, "\n") {
+			longest = maxInt(longest, textWidth(line))
+		}
+
+		return text + "\n" + strings.Repeat(underline, longest)
+	case HeadingHashes:
+		// Each line gets the prefix, so a wrapped heading is still a
+		// heading in Markdown
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = prefix + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return text
+	}
+}
+
+func (r *textRenderer) blockquote(n *RawNode, width int) string {
+	if width > 0 {
+		width = maxInt(width-2, 1)
+	}
+
+	lines := strings.Split(r.blocks(n.children, width, "\n\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// pre renders the text of a preformatted element verbatim
+func (r *textRenderer) pre(n *RawNode) string {
+	var b strings.Builder
+	rawText(n, &b)
+
+	// Like browsers, ignore a single newline directly after the opening tag
+	text := strings.TrimPrefix(r.plain(b.String()), "\n")
+	return r.styleLines(n, strings.TrimRight(text, "\n"))
+}
+
+// rawText writes the text of n and its descendants to b, as-is
+func rawText(n *RawNode, b *strings.Builder) {
+	switch {
+	case n.nodeType == textNode:
+		b.WriteString(html.UnescapeString(n.text))
+	case n.tag == "br":
+		b.WriteString("\n")
+	default:
+		eachVisible(n.children, func(c *RawNode) {
+			rawText(c, b)
+		})
+	}
+}
+
+func (r *textRenderer) rule(width int) string {
+	if width <= 0 {
+		width = DefaultTextOptions().LineWidth
+	}
+
+	return strings.Repeat("-", width)
+}
+
+type textTableRow struct {
+	cells  [][]string
+	header bool
+}
+
+// table renders a table as aligned columns, separating the header rows
+// from the body with a horizontal rule. Cells are never wrapped.
+func (r *textRenderer) table(n *RawNode) string {
+	var (
+		caption string
+		rows    []textTableRow
+		collect func(nodes []Node, inHead bool)
+	)
+
+	collect = func(nodes []Node, inHead bool) {
+		eachVisible(nodes, func(c *RawNode) {
+			switch c.tag {
+			case "caption":
+				caption = r.blocks(c.children, 0, "\n")
+			case "thead":
+				collect(c.children, true)
+			case "tbody", "tfoot":
+				collect(c.children, false)
+			case "tr":
+				row := textTableRow{header: true}
+				eachVisible(c.children, func(cell *RawNode) {
+					if cell.tag != "td" && cell.tag != "th" {
+						return
+					}
+
+					row.header = row.header && (inHead || cell.tag == "th")
+					lines := strings.Split(r.blocks(cell.children, 0, "\n"), "\n")
+					row.cells = append(row.cells, lines)
+				})
+
+				if len(row.cells) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		})
+	}
+	collect(n.children, false)
+
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row.cells {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			for _, line := range cell {
+				widths[i] = maxInt(widths[i], textWidth(line))
+			}
+		}
+	}
+
+	var out []string
+	if caption != "" {
+		out = append(out, caption)
+	}
+
+	for i, row := range rows {
+		height := 0
+		for _, cell := range row.cells {
+			height = maxInt(height, len(cell))
+		}
+
+		for l := 0; l < height; l++ {
+			cols := make([]string, len(widths))
+			for c := range widths {
+				text := ""
+				if c < len(row.cells) && l < len(row.cells[c]) {
+					text = row.cells[c][l]
+				}
+				cols[c] = text + strings.Repeat(" ", widths[c]-textWidth(text))
+			}
+			out = append(out, strings.TrimRight(strings.Join(cols, " | "), " "))
+		}
+
+		// Separate header rows from the rows that follow them
+		if row.header && i+1 < len(rows) && !rows[i+1].header {
+			cols := make([]string, len(widths))
+			for c, w := range widths {
+				cols[c] = strings.Repeat("-", w)
+			}
+			out = append(out, strings.Join(cols, "-+-"))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// inline writes the text content of an inline node to b
+func (r *textRenderer) inline(n *RawNode, b *strings.Builder) {
+	if n.nodeType == textNode {
+		// Line breaks in text are insignificant, so turn them into spaces
+		// and leave them to be collapsed when the paragraph is wrapped
+		b.WriteString(strings.Map(func(c rune) rune {
+			if isHTMLSpace(c) {
+				return ' '
+			}
+			return c
+		}, r.plain(html.UnescapeString(n.text))))
+		return
+	}
+
+	switch n.tag {
+	case "br":
+		b.WriteString("\n")
+		return
+	case "img":
+		if alt := n.attr("alt"); alt != "" {
+			b.WriteString("[" + r.plain(alt) + "]")
+		}
+		return
+	}
+
+	start, end := r.style(n)
+	b.WriteString(start)
+	eachVisible(n.children, func(c *RawNode) {
+		r.inline(c, b)
+	})
+	b.WriteString(end)
+
+	if n.tag == "a" {
+		r.link(n, b)
+	}
+}
+
+func (r *textRenderer) link(n *RawNode, b *strings.Builder) {
+	href := r.plain(n.attr("href"))
+	if href == "" {
+		return
+	}
+
+	switch r.opts.Links {
+	case LinkInline:
+		b.WriteString(" (" + href + ")")
+	case LinkReference:
+		b.WriteString(fmt.Sprintf(" [%d]", r.reference(href)))
+	}
+}
+
+// reference returns the 1-based reference number for href, reusing the
+// number of an earlier link to the same URL
+func (r *textRenderer) reference(href string) int {
+	for i, l := range r.links {
+		if l == href {
+			return i + 1
+		}
+	}
+
+	r.links = append(r.links, href)
+	return len(r.links)
+}
+
+// wrap collapses whitespace the way a browser would and wraps each line
+// of the result to width
+func (r *textRenderer) wrap(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.FieldsFunc(line, isHTMLSpace), " ")
+	}
+
+	return strings.Trim(WrapText(strings.Join(lines, "\n"), width), "\n")
+}
+
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
 }
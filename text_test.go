@@ -0,0 +1,208 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestElement_ToTextWith(t *testing.T) {
+	t.Run("disables wrapping", func(t *testing.T) {
+		text := strings.Repeat("word ", 30)
+		opts := DefaultTextOptions()
+		opts.LineWidth = 0
+
+		root := P().Text(text)
+		assert.Equal(t, strings.TrimSpace(text), root.ToTextWith(opts))
+	})
+
+	t.Run("wraps at custom width", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.LineWidth = 10
+
+		root := P().Text("one two three four")
+		assert.Equal(t, "one two\nthree four", root.ToTextWith(opts))
+	})
+
+	t.Run("renders reference links", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.Links = LinkReference
+
+		root := Div().Children(
+			P().Children(A().Href("https://yaak.app").Text("Yaak")),
+			P().Children(
+				A().Href("https://example.com").Text("Example"),
+				Text(" and "),
+				A().Href("https://yaak.app").Text("Yaak again"),
+			),
+		)
+		assert.Equal(t, strings.Join([]string{
+			"Yaak [1]",
+			"",
+			"Example [2] and Yaak again [1]",
+			"",
+			"[1] https://yaak.app",
+			"[2] https://example.com",
+		}, "\n"), root.ToTextWith(opts))
+	})
+
+	t.Run("hides links", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.Links = LinkHidden
+
+		root := P().Children(A().Href("https://yaak.app").Text("Yaak"))
+		assert.Equal(t, "Yaak", root.ToTextWith(opts))
+	})
+
+	t.Run("uses custom bullets", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.Bullets = []string{"*"}
+
+		root := Ul().Children(Li().Text("foo"), Li().Text("bar"))
+		assert.Equal(t, " * foo\n * bar", root.ToTextWith(opts))
+	})
+
+	t.Run("decorates headings", func(t *testing.T) {
+		root := Div().Children(
+			H1().Text("Title"),
+			H2().Text("Subtitle"),
+		)
+
+		opts := DefaultTextOptions()
+		opts.Headings = HeadingUnderline
+		assert.Equal(t, "Title\n=====\n\nSubtitle\n--------", root.ToTextWith(opts))
+
+		opts.Headings = HeadingHashes
+		assert.Equal(t, "# Title\n\n## Subtitle", root.ToTextWith(opts))
+	})
+
+	t.Run("wraps headings after their hashes", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.Headings = HeadingHashes
+		opts.LineWidth = 20
+
+		root := H2().Text("a long heading that wraps around")
+		assert.Equal(t, "## a long heading\n## that wraps around", root.ToTextWith(opts))
+	})
+
+	t.Run("skips comments and unescapes text", func(t *testing.T) {
+		root := P().Children(
+			Comment("hidden"),
+			Text(`Tom & "Jerry"`),
+		)
+		assert.Equal(t, `Tom & "Jerry"`, root.ToText())
+	})
+}
+
+func TestElement_ToText_Blocks(t *testing.T) {
+	t.Run("renders tables as columns", func(t *testing.T) {
+		root := Table().Children(
+			Caption().Text("People"),
+			Thead().Children(
+				Tr().Children(Th().Text("Name"), Th().Text("Age")),
+			),
+			Tbody().Children(
+				Tr().Children(Td().Text("Alice"), Td().Text("30")),
+				Tr().Children(Td().Text("Bob"), Td().Children(Text("4"), Br(), Text("5"))),
+			),
+		)
+		assert.Equal(t, strings.Join([]string{
+			"People",
+			"Name  | Age",
+			"------+----",
+			"Alice | 30",
+			"Bob   | 4",
+			"      | 5",
+		}, "\n"), root.ToText())
+	})
+
+	t.Run("prefixes blockquotes", func(t *testing.T) {
+		root := Blockquote().Children(
+			P().Text("First"),
+			P().Text("Second"),
+		)
+		assert.Equal(t, "> First\n>\n> Second", root.ToText())
+	})
+
+	t.Run("renders breaks, rules and images", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.LineWidth = 10
+
+		root := Div().Children(
+			P().Children(Text("one"), Br(), Text("two")),
+			Hr(),
+			P().Children(Img().Src("cat.png").Alt("A cat"), Img().Src("spacer.gif")),
+		)
+		assert.Equal(t, "one\ntwo\n\n----------\n\n[A cat]", root.ToTextWith(opts))
+	})
+
+	t.Run("preserves pre content", func(t *testing.T) {
+		root := Div().Children(
+			Pre().Children(
+				Code().Text("func main() {\n    println(\"hi\")\n}"),
+			),
+		)
+		assert.Equal(t, "func main() {\n    println(\"hi\")\n}", root.ToText())
+	})
+}
+
+func TestElement_ToText_Lists(t *testing.T) {
+	t.Run("indents nested lists", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.Bullets = []string{"-", "*", "+"}
+
+		root := Ul().Children(
+			Li().Children(
+				Text("Fruits"),
+				Ol().Children(
+					Li().Text("Apple"),
+					Li().Children(
+						Text("Banana"),
+						Ul().Children(Li().Text("Yellow")),
+					),
+				),
+			),
+			Li().Text("Vegetables"),
+		)
+		assert.Equal(t, strings.Join([]string{
+			" - Fruits",
+			"    1) Apple",
+			"    2) Banana",
+			"        + Yellow",
+			" - Vegetables",
+		}, "\n"), root.ToTextWith(opts))
+	})
+
+	t.Run("counts only list items", func(t *testing.T) {
+		root := Ol().Children(
+			Li().Text("One"),
+			Comment("skipped"),
+			Li().Text("Two"),
+		)
+		assert.Equal(t, " 1) One\n 2) Two", root.ToText())
+	})
+
+	t.Run("honors start, reversed and type", func(t *testing.T) {
+		root := Ol().Attr("start", "9").Children(Li().Text("Nine"), Li().Text("Ten"))
+		assert.Equal(t, "  9) Nine\n 10) Ten", root.ToText())
+
+		root = Ol().AttrBool("reversed").Children(Li().Text("Two"), Li().Text("One"))
+		assert.Equal(t, " 2) Two\n 1) One", root.ToText())
+
+		root = Ol().Type("a").Children(Li().Text("First"), Li().Attr("value", "27").Text("Next"))
+		assert.Equal(t, "  a) First\n aa) Next", root.ToText())
+
+		root = Ol().Type("I").Attr("start", "4").Children(Li().Text("Four"))
+		assert.Equal(t, " IV) Four", root.ToText())
+	})
+
+	t.Run("uses hanging indents", func(t *testing.T) {
+		opts := DefaultTextOptions()
+		opts.LineWidth = 17
+
+		root := Ul().Children(Li().Text("one two three four five"))
+		assert.Equal(t, " - one two three\n   four five", root.ToTextWith(opts))
+	})
+}
@@ -1,11 +1,44 @@
 package hagl
 
 import (
-	"regexp"
+	"strings"
+	"unicode/utf8"
 )
 
+// sanitizeAttrName removes the characters that can't be part of an attribute
+// name in HTML: controls, spaces, quotes, <, >, /, = and noncharacters. A <
+// is allowed by the spec, but it's a parse error.
 func sanitizeAttrName(attr string) string {
-	// Allow only a-z, A-Z, 0-9, and - (dash)
-	re := regexp.MustCompile(`[^a-zA-Z0-9-]`)
-	return re.ReplaceAllString(attr, "")
+	return strings.Map(func(r rune) rune {
+		if validAttrNameRune(r) {
+			return r
+		}
+		return -1
+	}, attr)
+}
+
+func validAttrNameRune(r rune) bool {
+	switch {
+	case r <= ' ', r >= 0x7f && r <= 0x9f, r == utf8.RuneError:
+		return false
+	case r == '"', r == '\'', r == '<', r == '>', r == '/', r == '=':
+		return false
+	case r >= 0xfdd0 && r <= 0xfdef, r&0xfffe == 0xfffe:
+		return false
+	}
+	return true
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
 }
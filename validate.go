@@ -0,0 +1,445 @@
+package hagl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is a problem with the structure of an HTML tree, found by
+// Validate
+type ValidationError struct {
+	// Path is the location of the element with the problem, like
+	// "html > body > ul > li[2]". Siblings with the same tag are numbered
+	// from 1.
+	Path string
+
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks the tree against the content model of HTML, returning a
+// problem for each:
+//
+//   - element in a parent it isn't allowed in, like <li> outside a list,
+//     <div> in <p> or <tr> outside <table>
+//   - child or text in a void element like <input>, or text where only
+//     elements are allowed, like in <ul>
+//   - interactive element inside <a> or <button>
+//   - id used by more than one element
+//   - attribute that's invalid, as reported by CheckAttrs, or unknown for
+//     its element
+//
+// The root of the tree can be anything, so components can be validated on
+// their own. Custom elements, SVG and MathML aren't checked, and neither
+// are attributes with a dash, colon or @ in their name, like hx-get or
+// @click, other than data-* and aria-* attributes.
+func Validate(node Node) []ValidationError {
+	v := &validator{ids: make(map[string]string)}
+	eachRootElement(node, v.element)
+	return v.errs
+}
+
+// StrictHTML renders the node as HTML, like ToHTML, unless Validate finds
+// any problems, which are returned as an error instead
+func StrictHTML(node Node) (string, error) {
+	problems := Validate(node)
+	if len(problems) == 0 {
+		return node.ToHTML(), nil
+	}
+
+	errs := make([]error, len(problems))
+	for i, p := range problems {
+		errs[i] = p
+	}
+	return "", errors.Join(errs...)
+}
+
+type validator struct {
+	errs []ValidationError
+
+	// ids are the paths of the elements using each id
+	ids map[string]string
+}
+
+func (v *validator) report(path, format string, a ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// eachElement calls fn with each visible element in the children of n, along
+// with its ancestor elements and its path. The children of the element are
+// visited when fn returns true.
+func eachElement(n *RawNode, ancestors []*RawNode, path string, fn func(el *RawNode, ancestors []*RawNode, path string) bool) {
+	children := visibleChildren(n)
+
+	count := make(map[string]int)
+	for _, c := range children {
+		if c.nodeType == tagNode {
+			count[c.tag]++
+		}
+	}
+
+	seen := make(map[string]int)
+	for _, c := range children {
+		if c.nodeType != tagNode {
+			continue
+		}
+
+		seen[c.tag]++
+		childPath := c.tag
+		if count[c.tag] > 1 {
+			childPath += "[" + strconv.Itoa(seen[c.tag]) + "]"
+		}
+		if path != "" {
+			childPath = path + " > " + childPath
+		}
+
+		if fn(c, ancestors, childPath) {
+			eachElement(c, append(ancestors[:len(ancestors):len(ancestors)], c), childPath, fn)
+		}
+	}
+}
+
+// eachRootElement calls eachElement for the tree, including its root
+func eachRootElement(node Node, fn func(el *RawNode, ancestors []*RawNode, path string) bool) {
+	eachElement(Fragment().Children(node).GetNode(), nil, "", fn)
+}
+
+// element validates n, returning whether to validate its children
+func (v *validator) element(n *RawNode, ancestors []*RawNode, path string) bool {
+	v.placement(n, ancestors, path)
+
+	if id := n.attr("id"); id != "" {
+		if other, ok := v.ids[id]; ok {
+			v.report(path, "duplicate id %q, also used by %s", id, other)
+		} else {
+			v.ids[id] = path
+		}
+	}
+
+	// Foreign and custom elements have content models of their own
+	if _, ok := elementAttrs[n.tag]; !ok {
+		return false
+	}
+
+	for _, a := range n.attrs {
+		if reason := checkAttr(a); reason != "" {
+			v.report(path, "%s=%q: %s", a.name, a.value, reason)
+		} else if !knownAttr(n.tag, a.name) {
+			v.report(path, "%s=%q: unknown attribute for <%s>", a.name, a.value, n.tag)
+		}
+	}
+
+	children := visibleChildren(n)
+	if n.selfClosing {
+		if len(children) > 0 {
+			v.report(path, "<%s> is a void element and can't have children", n.tag)
+		}
+		return false
+	}
+
+	if _, ok := allowedChildren[n.tag]; ok {
+		for _, c := range children {
+			if c.nodeType == textNode && strings.TrimSpace(c.text) != "" {
+				v.report(path, "text isn't allowed in <%s>", n.tag)
+				break
+			}
+		}
+	}
+
+	return true
+}
+
+// placement checks that the element is allowed where it is
+func (v *validator) placement(n *RawNode, ancestors []*RawNode, path string) {
+	if len(ancestors) == 0 {
+		return
+	}
+
+	parent := ancestors[len(ancestors)-1]
+	if parents, ok := requiredParents[n.tag]; ok {
+		if !slices.Contains(parents, parent.tag) {
+			v.report(path, "<%s> must be in %s", n.tag, tagList(parents))
+		}
+		return
+	}
+
+	if children, ok := allowedChildren[parent.tag]; ok {
+		if !slices.Contains(children, n.tag) && n.tag != "script" && n.tag != "template" {
+			v.report(path, "<%s> isn't allowed in <%s>", n.tag, parent.tag)
+		}
+		return
+	}
+
+	// Transparent elements, like <a>, have the content model of their parent
+	context := parent
+	for i := len(ancestors) - 1; i > 0 && slices.Contains(transparentEls, context.tag); i-- {
+		context = ancestors[i-1]
+	}
+
+	if slices.Contains(phrasingOnlyEls, context.tag) && slices.Contains(flowOnlyEls, n.tag) {
+		v.report(path, "<%s> isn't allowed in <%s>", n.tag, context.tag)
+		return
+	}
+
+	if isInteractive(n) {
+		for i := len(ancestors) - 1; i >= 0; i-- {
+			if a := ancestors[i]; a.tag == "a" || a.tag == "button" {
+				v.report(path, "<%s> isn't allowed inside <%s>", n.tag, a.tag)
+				return
+			}
+		}
+	}
+}
+
+// visibleChildren returns the children of n that are rendered, with the
+// children of fragments in their place
+func visibleChildren(n *RawNode) []*RawNode {
+	var children []*RawNode
+	for _, child := range n.children {
+		c := child.GetNode()
+		switch {
+		case c.hide, c.nodeType == commentNode, c.nodeType == doctypeNode, c.nodeType == headNode:
+		case c.nodeType == fragmentNode:
+			children = append(children, visibleChildren(c)...)
+		default:
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+func isInteractive(n *RawNode) bool {
+	switch n.tag {
+	case "a", "button", "details", "embed", "iframe", "label", "select", "textarea":
+		return true
+	case "input":
+		return !strings.EqualFold(n.attr("type"), "hidden")
+	case "audio", "video":
+		return n.hasAttr("controls")
+	}
+	return false
+}
+
+func knownAttr(tag, name string) bool {
+	name = strings.ToLower(name)
+	if strings.ContainsAny(name, "-:@") || strings.HasPrefix(name, "on") {
+		return true
+	}
+
+	return slices.Contains(globalAttrs, name) ||
+		slices.Contains(elementAttrs[tag], name) ||
+		(slices.Contains(legacyAttrEls, tag) && slices.Contains(legacyAttrs, name))
+}
+
+// tagList formats tags like "<ul>, <ol> or <menu>"
+func tagList(tags []string) string {
+	items := make([]string, len(tags))
+	for i, t := range tags {
+		items[i] = "<" + t + ">"
+	}
+
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+// requiredParents are the only elements that each element can be a child of
+var requiredParents = map[string][]string{
+	"head":       {"html"},
+	"body":       {"html"},
+	"title":      {"head"},
+	"base":       {"head"},
+	"li":         {"ul", "ol", "menu"},
+	"dt":         {"dl", "div"},
+	"dd":         {"dl", "div"},
+	"caption":    {"table"},
+	"colgroup":   {"table"},
+	"col":        {"colgroup"},
+	"thead":      {"table"},
+	"tbody":      {"table"},
+	"tfoot":      {"table"},
+	"tr":         {"table", "thead", "tbody", "tfoot"},
+	"td":         {"tr"},
+	"th":         {"tr"},
+	"option":     {"select", "datalist", "optgroup"},
+	"optgroup":   {"select"},
+	"legend":     {"fieldset"},
+	"figcaption": {"figure"},
+	"summary":    {"details"},
+	"rt":         {"ruby"},
+	"rp":         {"ruby"},
+	"source":     {"audio", "video", "picture"},
+	"track":      {"audio", "video"},
+	"param":      {"object"},
+}
+
+// allowedChildren are the only elements that each element can contain,
+// besides <script> and <template>. Text isn't allowed in them either.
+var allowedChildren = map[string][]string{
+	"html":     {"head", "body"},
+	"head":     {"base", "link", "meta", "noscript", "style", "title"},
+	"ul":       {"li"},
+	"ol":       {"li"},
+	"menu":     {"li"},
+	"dl":       {"dt", "dd", "div"},
+	"table":    {"caption", "colgroup", "thead", "tbody", "tfoot", "tr"},
+	"thead":    {"tr"},
+	"tbody":    {"tr"},
+	"tfoot":    {"tr"},
+	"tr":       {"td", "th"},
+	"colgroup": {"col"},
+	"select":   {"option", "optgroup", "hr"},
+	"optgroup": {"option"},
+}
+
+// phrasingOnlyEls can only contain phrasing content, like text and <span>
+var phrasingOnlyEls = []string{
+	"p", "h1", "h2", "h3", "h4", "h5", "h6", "pre", "span", "em", "strong",
+	"i", "b", "u", "sub", "sup", "code", "small", "cite", "dfn", "abbr",
+	"time", "var", "samp", "kbd", "s", "q", "mark", "bdi", "bdo", "label",
+	"button", "output", "progress", "meter", "rt", "rp",
+}
+
+// flowOnlyEls aren't phrasing content, so can't be in phrasingOnlyEls
+var flowOnlyEls = []string{
+	"address", "article", "aside", "blockquote", "details", "div", "dl",
+	"fieldset", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5",
+	"h6", "header", "hr", "main", "menu", "nav", "ol", "p", "pre", "section",
+	"table", "ul",
+}
+
+// transparentEls have the content model of their parent
+var transparentEls = []string{"a", "ins", "del", "object", "canvas", "audio", "video", "noscript"}
+
+// globalAttrs can be used on any element
+var globalAttrs = []string{
+	"accesskey", "autocapitalize", "autofocus", "class", "contenteditable",
+	"dir", "draggable", "enterkeyhint", "hidden", "id", "inert", "inputmode",
+	"is", "itemid", "itemprop", "itemref", "itemscope", "itemtype", "lang",
+	"nonce", "popover", "role", "slot", "spellcheck", "style", "tabindex",
+	"title", "translate",
+}
+
+// legacyAttrs are obsolete, but still needed for HTML email
+var (
+	legacyAttrs   = []string{"align", "valign", "bgcolor", "border", "cellpadding", "cellspacing", "width", "height"}
+	legacyAttrEls = []string{"table", "thead", "tbody", "tfoot", "tr", "td", "th", "img"}
+)
+
+var mediaAttrs = []string{"autoplay", "controls", "crossorigin", "loop", "muted", "preload", "src"}
+
+// elementAttrs are the attributes of each element in elements.go, besides
+// globalAttrs. Elements that aren't listed, like <svg>, aren't checked.
+var elementAttrs = map[string][]string{
+	"html":       {"manifest", "xmlns"},
+	"head":       nil,
+	"title":      nil,
+	"body":       nil,
+	"base":       {"href", "target"},
+	"link":       {"as", "blocking", "color", "crossorigin", "disabled", "fetchpriority", "href", "hreflang", "imagesizes", "imagesrcset", "integrity", "media", "referrerpolicy", "rel", "sizes", "type"},
+	"meta":       {"charset", "content", "media", "name", "property"},
+	"script":     {"async", "blocking", "crossorigin", "defer", "fetchpriority", "integrity", "nomodule", "referrerpolicy", "src", "type"},
+	"style":      {"blocking", "media", "type"},
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"div":        nil,
+	"p":          nil,
+	"hr":         nil,
+	"pre":        nil,
+	"blockquote": {"cite"},
+	"span":       nil,
+	"a":          {"download", "href", "hreflang", "ping", "referrerpolicy", "rel", "target", "type"},
+	"code":       nil,
+	"em":         nil,
+	"strong":     nil,
+	"i":          nil,
+	"b":          nil,
+	"u":          nil,
+	"sub":        nil,
+	"sup":        nil,
+	"br":         nil,
+	"ol":         {"reversed", "start", "type"},
+	"ul":         nil,
+	"li":         {"value"},
+	"dl":         nil,
+	"dt":         nil,
+	"dd":         nil,
+	"img":        {"alt", "crossorigin", "decoding", "fetchpriority", "height", "ismap", "loading", "referrerpolicy", "sizes", "src", "srcset", "usemap", "width"},
+	"iframe":     {"allow", "allowfullscreen", "height", "loading", "name", "referrerpolicy", "sandbox", "src", "srcdoc", "width"},
+	"canvas":     {"height", "width"},
+	"form":       {"action", "autocomplete", "enctype", "method", "name", "novalidate", "rel", "target"},
+	"input":      {"accept", "alt", "autocomplete", "capture", "checked", "dirname", "disabled", "form", "formaction", "formenctype", "formmethod", "formnovalidate", "formtarget", "height", "list", "max", "maxlength", "min", "minlength", "multiple", "name", "pattern", "placeholder", "popovertarget", "popovertargetaction", "readonly", "required", "size", "src", "step", "type", "value", "width"},
+	"textarea":   {"autocomplete", "cols", "dirname", "disabled", "form", "maxlength", "minlength", "name", "placeholder", "readonly", "required", "rows", "wrap"},
+	"button":     {"disabled", "form", "formaction", "formenctype", "formmethod", "formnovalidate", "formtarget", "name", "popovertarget", "popovertargetaction", "type", "value"},
+	"select":     {"autocomplete", "disabled", "form", "multiple", "name", "required", "size"},
+	"option":     {"disabled", "label", "selected", "value"},
+	"fieldset":   {"disabled", "form", "name"},
+	"legend":     nil,
+	"label":      {"for"},
+	"datalist":   nil,
+	"optgroup":   {"disabled", "label"},
+	"output":     {"for", "form", "name"},
+	"progress":   {"max", "value"},
+	"meter":      {"high", "low", "max", "min", "optimum", "value"},
+	"section":    nil,
+	"nav":        nil,
+	"article":    nil,
+	"aside":      nil,
+	"header":     nil,
+	"footer":     nil,
+	"address":    nil,
+	"main":       nil,
+	"figure":     nil,
+	"figcaption": nil,
+	"table":      nil,
+	"caption":    nil,
+	"colgroup":   {"span"},
+	"col":        {"span"},
+	"tbody":      nil,
+	"thead":      nil,
+	"tfoot":      nil,
+	"tr":         nil,
+	"td":         {"colspan", "headers", "rowspan"},
+	"th":         {"abbr", "colspan", "headers", "rowspan", "scope"},
+	"audio":      mediaAttrs,
+	"video":      append([]string{"height", "playsinline", "poster", "width"}, mediaAttrs...),
+	"source":     {"height", "media", "sizes", "src", "srcset", "type", "width"},
+	"track":      {"default", "kind", "label", "src", "srclang"},
+	"embed":      {"height", "src", "type", "width"},
+	"object":     {"data", "form", "height", "name", "type", "width"},
+	"param":      {"name", "value"},
+	"ins":        {"cite", "datetime"},
+	"del":        {"cite", "datetime"},
+	"small":      nil,
+	"cite":       nil,
+	"dfn":        nil,
+	"abbr":       nil,
+	"time":       {"datetime"},
+	"var":        nil,
+	"samp":       nil,
+	"kbd":        nil,
+	"s":          nil,
+	"q":          {"cite"},
+	"mark":       nil,
+	"ruby":       nil,
+	"rt":         nil,
+	"rp":         nil,
+	"bdi":        nil,
+	"bdo":        nil,
+	"wbr":        nil,
+	"details":    {"name", "open"},
+	"summary":    nil,
+	"menuitem":   {"checked", "command", "default", "disabled", "icon", "label", "radiogroup", "type"},
+	"menu":       nil,
+}
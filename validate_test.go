@@ -0,0 +1,108 @@
+package hagl_test
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestValidate(t *testing.T) {
+	messages := func(node Node) []string {
+		var msgs []string
+		for _, err := range Validate(node) {
+			msgs = append(msgs, err.Error())
+		}
+		return msgs
+	}
+
+	t.Run("accepts valid markup", func(t *testing.T) {
+		root := Document(DocumentOptions{Title: "Home"}).Children(
+			Nav().Children(Ul().Children(
+				Li().Children(A().Href("/").Text("Home")),
+				Li().Children(A().Href("/about").Children(Span().Text("About"))),
+			)),
+			P().Text("Hi ").Children(Strong().Text("there"), Br()),
+			A().Href("/sign-up").Children(Div().Text("Sign up")),
+			Table().Attr("cellpadding", "0").Children(
+				Thead().Children(Tr().Children(Th().Attr("scope", "col").Text("Name"))),
+				Tbody().Children(Fragment().Children(Tr().Children(Td().Text("Jo")))),
+			),
+			Form().Method("post").Children(
+				Label().Attr("for", "email").Text("Email"),
+				Input().ID("email").Type("email").Name("email").Attr("required", "").HxPost("/check"),
+				Select().Name("plan").Children(Option().Value("free").Text("Free")),
+				Button().Type("submit").Aria(ARIALabel, "Sign up").Text("Go"),
+			),
+			NewElement("my-widget").Attr("size", "large").Children(Li()),
+		)
+		assert.Empty(t, messages(root))
+	})
+
+	t.Run("validates a component on its own", func(t *testing.T) {
+		item := NewComponent(func(children []Node) Node {
+			return Li().Children(children...)
+		})
+		assert.Empty(t, messages(item().Text("One")))
+		assert.Equal(t, []string{"div > li: <li> must be in <ul>, <ol> or <menu>"}, messages(Div().Children(item())))
+	})
+
+	t.Run("reports elements in the wrong place", func(t *testing.T) {
+		root := Div().Children(
+			Li().Text("Loose"),
+			P().Children(Div().Text("Block")),
+			P().Children(A().Children(Ul())),
+			Tr().Children(Td()),
+			Ul().Children(Div(), Text("text")),
+			A().Href("/").Children(Button().Text("Nested")),
+		)
+		assert.Equal(t, []string{
+			"div > li: <li> must be in <ul>, <ol> or <menu>",
+			"div > p[1] > div: <div> isn't allowed in <p>",
+			"div > p[2] > a > ul: <ul> isn't allowed in <p>",
+			"div > tr: <tr> must be in <table>, <thead>, <tbody> or <tfoot>",
+			"div > ul: text isn't allowed in <ul>",
+			"div > ul > div: <div> isn't allowed in <ul>",
+			"div > a > button: <button> isn't allowed inside <a>",
+		}, messages(root))
+	})
+
+	t.Run("reports children of void elements", func(t *testing.T) {
+		root := Form().Children(Input().Text("nope"), Br().Children(Span()).If(false))
+		assert.Equal(t, []string{"form > input: <input> is a void element and can't have children"}, messages(root))
+	})
+
+	t.Run("reports duplicate ids", func(t *testing.T) {
+		root := Main().Children(
+			Section().ID("intro"),
+			Section().Children(H2().ID("intro")),
+			Section().ID("hidden").If(false),
+			Section().ID("hidden"),
+		)
+		assert.Equal(t, []string{
+			`main > section[2] > h2: duplicate id "intro", also used by main > section[1]`,
+		}, messages(root))
+	})
+
+	t.Run("reports attributes", func(t *testing.T) {
+		root := Div().Attr("foo", "bar").Children(
+			Img().Src("/a.png").Alt("").Attr("href", "/"),
+			Span().Aria(ARIAHidden, "yes").Attr("x-data", "{}").Attr("@click", "go()"),
+		)
+		assert.Equal(t, []string{
+			`div: foo="bar": unknown attribute for <div>`,
+			`div > img: href="/": unknown attribute for <img>`,
+			`div > span: aria-hidden="yes": value must be one of true, false, undefined`,
+		}, messages(root))
+	})
+
+	t.Run("renders strictly", func(t *testing.T) {
+		html, err := StrictHTML(Ul().Children(Li().Text("One")))
+		assert.NoError(t, err)
+		assert.Equal(t, "<ul><li>One</li></ul>", html)
+
+		_, err = StrictHTML(Ul().Children(Li(), P(), P()))
+		assert.EqualError(t, err, "ul > p[1]: <p> isn't allowed in <ul>\nul > p[2]: <p> isn't allowed in <ul>")
+	})
+}
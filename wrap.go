@@ -0,0 +1,293 @@
+package hagl
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WrapOptions configures WrapTextWith
+type WrapOptions struct {
+	// Width is the maximum display width of a line, measured in terminal
+	// columns. A value of zero or less disables wrapping.
+	Width int
+
+	// BreakWords splits words (like long URLs) that don't fit on a line
+	// by themselves. Otherwise, such words are placed on their own line.
+	BreakWords bool
+
+	// Indent is prepended to every line after the first, and counts
+	// toward the width of the line
+	Indent string
+}
+
+// WrapText wraps text so that no line is wider than lineLength columns.
+// Existing line breaks are kept, and lines only break at spaces or between
+// East Asian wide characters.
+func WrapText(text string, lineLength int) string {
+	return WrapTextWith(text, WrapOptions{Width: lineLength})
+}
+
+// WrapTextWith is the same as WrapText, but accepts options
+func WrapTextWith(text string, opts WrapOptions) string {
+	w := &wrapper{opts: opts, empty: true}
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			w.newLine()
+		}
+
+		for _, t := range tokenizeWrap(line) {
+			w.add(t)
+		}
+	}
+
+	return strings.Join(append(w.lines, w.line.String()), "\n")
+}
+
+type wrapToken struct {
+	// space is the whitespace preceding the word, which is dropped if the
+	// line is broken before the word
+	space string
+	word  string
+}
+
+// tokenizeWrap splits a line into words at spaces. Wide characters are
+// words of their own, since lines can break between them.
+func tokenizeWrap(line string) []wrapToken {
+	var (
+		tokens []wrapToken
+		space  strings.Builder
+		word   strings.Builder
+	)
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, wrapToken{space: space.String(), word: word.String()})
+			space.Reset()
+			word.Reset()
+		}
+	}
+
+	for _, r := range line {
+		switch {
+		case r == ' ' || r == '\t':
+			flush()
+			space.WriteRune(r)
+		case runeWidth(r) == 2:
+			flush()
+			word.WriteRune(r)
+			flush()
+		case runeWidth(r) == 0 && word.Len() == 0 && space.Len() == 0 && len(tokens) > 0:
+			// Keep combining marks attached to the preceding wide character
+			tokens[len(tokens)-1].word += string(r)
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	// Keep trailing whitespace so lines that aren't wrapped are unchanged
+	if space.Len() > 0 {
+		tokens = append(tokens, wrapToken{space: space.String()})
+	}
+
+	return tokens
+}
+
+type wrapper struct {
+	opts  WrapOptions
+	lines []string
+	line  strings.Builder
+
+	// width is the display width of the current line
+	width int
+
+	// empty is whether the current line has no words yet
+	empty bool
+}
+
+func (w *wrapper) newLine() {
+	w.lines = append(w.lines, w.line.String())
+	w.line.Reset()
+	w.line.WriteString(w.opts.Indent)
+	w.width = textWidth(w.opts.Indent)
+	w.empty = true
+}
+
+func (w *wrapper) add(t wrapToken) {
+	spaceWidth, wordWidth := textWidth(t.space), textWidth(t.word)
+	if w.opts.Width <= 0 || w.width+spaceWidth+wordWidth <= w.opts.Width {
+		w.write(t.space+t.word, spaceWidth+wordWidth)
+		return
+	}
+
+	// Trailing whitespace that doesn't fit is dropped
+	if t.word == "" {
+		return
+	}
+
+	// Break before the word, unless it would leave an empty line
+	if !w.empty {
+		w.newLine()
+	} else if t.space != "" {
+		w.write(t.space, spaceWidth)
+	}
+
+	if !w.opts.BreakWords {
+		w.write(t.word, wordWidth)
+		return
+	}
+
+	// Split the word into pieces that fit on the remaining lines
+	for _, r := range t.word {
+		rw := runeWidth(r)
+		if !w.empty && w.width+rw > w.opts.Width {
+			w.newLine()
+		}
+		w.write(string(r), rw)
+	}
+}
+
+func (w *wrapper) write(s string, width int) {
+	w.line.WriteString(s)
+	w.width += width
+	w.empty = w.empty && strings.TrimSpace(s) == ""
+}
+
+// textWidth returns the number of columns needed to display s
+func textWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := escapeLength(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += runeWidth(r)
+		i += size
+	}
+	return width
+}
+
+// escapeLength returns the length of the ANSI escape sequence at the start
+// of s, or zero if there is none. Escape sequences take no space when
+// displayed in a terminal.
+func escapeLength(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+
+	switch s[1] {
+	case '[':
+		// Control sequences end with a byte in the range @ to ~
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		// Operating system commands end with BEL or ESC \
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 0
+	}
+
+	return len(s)
+}
+
+// runeWidth returns the number of columns needed to display r. Combining
+// and control characters take no space, while East Asian wide and
+// fullwidth characters (including most emoji) take two.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.IsControl(r):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r == 0x200B:
+		return 0
+	}
+
+	for _, rng := range wideRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// wideRanges lists the East Asian wide and fullwidth ranges, plus the
+// emoji blocks that terminals display with two columns
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x231A, 0x231B},   // Watch, hourglass
+	{0x2329, 0x232A},   // Angle brackets
+	{0x23E9, 0x23EC},   // Media controls
+	{0x23F0, 0x23F0},   // Alarm clock
+	{0x23F3, 0x23F3},   // Hourglass
+	{0x25FD, 0x25FE},   // Small squares
+	{0x2614, 0x2615},   // Umbrella, hot beverage
+	{0x2648, 0x2653},   // Zodiac
+	{0x267F, 0x267F},   // Wheelchair
+	{0x2693, 0x2693},   // Anchor
+	{0x26A1, 0x26A1},   // High voltage
+	{0x26AA, 0x26AB},   // Circles
+	{0x26BD, 0x26BE},   // Soccer, baseball
+	{0x26C4, 0x26C5},   // Snowman, sun
+	{0x26CE, 0x26CE},   // Ophiuchus
+	{0x26D4, 0x26D4},   // No entry
+	{0x26EA, 0x26EA},   // Church
+	{0x26F2, 0x26F3},   // Fountain, golf
+	{0x26F5, 0x26F5},   // Sailboat
+	{0x26FA, 0x26FA},   // Tent
+	{0x26FD, 0x26FD},   // Fuel pump
+	{0x2705, 0x2705},   // Check mark
+	{0x270A, 0x270B},   // Fists
+	{0x2728, 0x2728},   // Sparkles
+	{0x274C, 0x274C},   // Cross mark
+	{0x274E, 0x274E},   // Cross mark
+	{0x2753, 0x2755},   // Question marks
+	{0x2757, 0x2757},   // Exclamation mark
+	{0x2795, 0x2797},   // Math symbols
+	{0x27B0, 0x27B0},   // Curly loop
+	{0x27BF, 0x27BF},   // Double curly loop
+	{0x2B1B, 0x2B1C},   // Large squares
+	{0x2B50, 0x2B50},   // Star
+	{0x2B55, 0x2B55},   // Circle
+	{0x2E80, 0x303E},   // CJK radicals, symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo extended A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // Vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small form variants
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x16FE0, 0x16FE4}, // Ideographic symbols
+	{0x17000, 0x18AFF}, // Tangut
+	{0x1B000, 0x1B2FF}, // Kana supplement and extensions
+	{0x1F004, 0x1F004}, // Mahjong tile
+	{0x1F0CF, 0x1F0CF}, // Playing card
+	{0x1F18E, 0x1F18E}, // AB button
+	{0x1F191, 0x1F19A}, // Squared words
+	{0x1F200, 0x1F251}, // Enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // Pictographs and emoticons
+	{0x1F680, 0x1F6FF}, // Transport and map symbols
+	{0x1F7E0, 0x1F7EB}, // Colored circles and squares
+	{0x1F90C, 0x1F9FF}, // Supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // Symbols and pictographs extended A
+	{0x20000, 0x2FFFD}, // CJK unified ideographs extensions B-F
+	{0x30000, 0x3FFFD}, // CJK unified ideographs extension G
+}
@@ -0,0 +1,50 @@
+package hagl_test
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+
+	. "github.com/gschier/hagl"
+)
+
+func TestWrapText(t *testing.T) {
+	t.Run("wraps at spaces", func(t *testing.T) {
+		assert.Equal(t, "The quick\nbrown fox\njumps", WrapText("The quick brown fox jumps", 10))
+	})
+
+	t.Run("keeps existing line breaks and spaces", func(t *testing.T) {
+		assert.Equal(t, "one  two\n\n  three", WrapText("one  two\n\n  three", 20))
+	})
+
+	t.Run("puts long words on their own line", func(t *testing.T) {
+		assert.Equal(t, "https://example.com/long\nok", WrapText("https://example.com/long ok", 10))
+	})
+
+	t.Run("measures wide characters", func(t *testing.T) {
+		assert.Equal(t, "日本語の\nテキスト", WrapText("日本語のテキスト", 8))
+		assert.Equal(t, "café au\nlait", WrapText("café au lait", 8))
+		assert.Equal(t, "🎉 🎉\n🎉", WrapText("🎉 🎉 🎉", 5))
+	})
+
+	t.Run("doesn't break at non-breaking spaces", func(t *testing.T) {
+		assert.Equal(t, "a\n10 km", WrapText("a 10 km", 6))
+	})
+
+	t.Run("disables wrapping", func(t *testing.T) {
+		text := strings.Repeat("word ", 30)
+		assert.Equal(t, text, WrapText(text, 0))
+	})
+}
+
+func TestWrapTextWith(t *testing.T) {
+	t.Run("breaks long words", func(t *testing.T) {
+		opts := WrapOptions{Width: 10, BreakWords: true}
+		assert.Equal(t, "see\nhttps://ex\nample.com/\nlong", WrapTextWith("see https://example.com/long", opts))
+	})
+
+	t.Run("adds hanging indent", func(t *testing.T) {
+		opts := WrapOptions{Width: 13, Indent: "    "}
+		assert.Equal(t, "one two three\n    four five\n    six", WrapTextWith("one two three four five six", opts))
+	})
+}